@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	domain "goodblast/internal/domain/errors"
+)
+
+var (
+	adminTournamentID int64
+	adminUserID       int64
+	adminCoins        int64
+)
+
+var tournamentCmd = &cobra.Command{
+	Use:   "tournament",
+	Short: "Manage daily tournaments",
+}
+
+var tournamentCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create and start today's tournament",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runApplication(cmd, func(ctx context.Context, app *application) error {
+			tournamentID, err := createAndStartNewTournament(ctx, app.tournamentService)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Tournament %d created and started\n", tournamentID)
+			return nil
+		})
+	},
+}
+
+var tournamentCloseCmd = &cobra.Command{
+	Use:   "close",
+	Short: "Close a tournament, the active one unless --id is given",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runApplication(cmd, func(ctx context.Context, app *application) error {
+			tournamentID, err := tournamentIDOrActive(ctx, app)
+			if err != nil {
+				return err
+			}
+			if err := app.tournamentService.CloseTournament(ctx, tournamentID); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Tournament %d closed\n", tournamentID)
+			return nil
+		})
+	},
+}
+
+var tournamentFinalizeCmd = &cobra.Command{
+	Use:   "finalize",
+	Short: "Close a tournament if needed and store the rewards of its top ten",
+	Long:  "Closes the tournament, the active one unless --id is given, and stores the rewards of its top ten players for them to claim. Players already rewarded for the tournament are skipped, so running it again is safe.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runApplication(cmd, func(ctx context.Context, app *application) error {
+			tournamentID, err := tournamentIDOrActive(ctx, app)
+			if err != nil {
+				return err
+			}
+			err = app.tournamentService.CloseTournament(ctx, tournamentID)
+			if err != nil && !errors.Is(err, domain.ErrTournamentAlreadyEnded) {
+				return err
+			}
+			if err := app.tournamentService.StoreRewards(ctx, tournamentID); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Tournament %d finalized\n", tournamentID)
+			return nil
+		})
+	},
+}
+
+var leaderboardCmd = &cobra.Command{
+	Use:   "leaderboard",
+	Short: "Maintain the Redis leaderboards",
+}
+
+var leaderboardRebuildCmd = &cobra.Command{
+	Use:   "rebuild",
+	Short: "Rebuild the leaderboards from Postgres",
+	Long:  "Rebuilds the global and country leaderboards, and the boards of --tournament-id when given, from Postgres.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runApplication(cmd, func(ctx context.Context, app *application) error {
+			report, err := app.leaderboardMaintenanceService.Rebuild(ctx, adminTournamentID)
+			if err != nil {
+				return err
+			}
+			return printJSON(cmd, report)
+		})
+	},
+}
+
+var userCmd = &cobra.Command{
+	Use:   "user",
+	Short: "Manage users",
+}
+
+var userGrantCoinsCmd = &cobra.Command{
+	Use:   "grant-coins",
+	Short: "Add coins to a user's balance",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if adminCoins == 0 {
+			return errors.New("--amount must not be zero")
+		}
+		return runApplication(cmd, func(ctx context.Context, app *application) error {
+			user, err := app.userService.GrantCoins(ctx, adminUserID, adminCoins)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "User %d now has %d coins\n", user.ID, user.Coins)
+			return nil
+		})
+	},
+}
+
+func init() {
+	tournamentCloseCmd.Flags().Int64Var(&adminTournamentID, "id", 0, "tournament ID")
+	tournamentFinalizeCmd.Flags().Int64Var(&adminTournamentID, "id", 0, "tournament ID")
+	tournamentCmd.AddCommand(tournamentCreateCmd, tournamentCloseCmd, tournamentFinalizeCmd)
+
+	leaderboardRebuildCmd.Flags().Int64Var(&adminTournamentID, "tournament-id", 0, "tournament whose boards are rebuilt as well")
+	leaderboardCmd.AddCommand(leaderboardRebuildCmd)
+
+	userGrantCoinsCmd.Flags().Int64Var(&adminUserID, "user-id", 0, "user ID")
+	userGrantCoinsCmd.Flags().Int64Var(&adminCoins, "amount", 0, "coins to add, negative to deduct; a deduction larger than the balance is refused")
+	_ = userGrantCoinsCmd.MarkFlagRequired("user-id")
+	_ = userGrantCoinsCmd.MarkFlagRequired("amount")
+	userCmd.AddCommand(userGrantCoinsCmd)
+}
+
+func tournamentIDOrActive(ctx context.Context, app *application) (int64, error) {
+	if adminTournamentID != 0 {
+		return adminTournamentID, nil
+	}
+
+	tournament, err := app.tournamentService.GetActiveTournament(ctx)
+	if err != nil || tournament == nil {
+		return 0, errors.New("no active tournament, pass --id")
+	}
+	return tournament.ID, nil
+}
+
+func printJSON(cmd *cobra.Command, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), string(data))
+	return nil
+}
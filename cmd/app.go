@@ -0,0 +1,417 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	ckafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/redis/go-redis/v9"
+	"github.com/spf13/cobra"
+	"github.com/uptrace/bun"
+	appconfig "goodblast/config"
+	"goodblast/internal/application/repository"
+	"goodblast/internal/application/service"
+	"goodblast/internal/infrastructure/kafka/producer"
+	"goodblast/internal/infrastructure/postgres"
+	"goodblast/internal/infrastructure/redisclient"
+	"goodblast/pkg/auth"
+	"goodblast/pkg/cache"
+	"goodblast/pkg/health"
+	"goodblast/pkg/lifecycle"
+	"goodblast/pkg/log"
+	"goodblast/pkg/ratelimit"
+	"goodblast/pkg/tracing"
+	"os"
+	"time"
+)
+
+// shutdownTimeout bounds how long stopping the application may take.
+const shutdownTimeout = 30 * time.Second
+
+// application is the container of the components shared by every command. It
+// builds each of them once, passing dependencies through constructors, and
+// registers how it is started and stopped with the lifecycle.
+type application struct {
+	config    *appconfig.Config
+	lifecycle *lifecycle.Lifecycle
+	health    *health.Registry
+
+	database             *bun.DB
+	redisCl              *redis.Client
+	producer             *ckafka.Producer
+	dynamicConfigService appconfig.IDynamicConfigService
+	keyring              *auth.Keyring
+	tokenAuth            auth.IAuth
+	tokenDenylist        auth.ITokenDenylist
+	loginLockout         auth.ILoginLockout
+	rateLimiter          ratelimit.ILimiter
+	serviceAuth          *auth.ServiceAuthenticator
+
+	userRepository           repository.IUserRepository
+	tournamentRepository     repository.ITournamentRepository
+	tournamentUserRepository repository.ITournamentUserRepository
+	leaderboardStore         repository.ILeaderboardStore
+	leaderboardCache         cache.Cache
+
+	userService                   service.IUserService
+	tokenService                  service.ITokenService
+	tournamentService             service.ITournamentService
+	leaderboardEventService       service.ILeaderboardEventService
+	leaderboardService            service.ILeaderboardService
+	leaderboardMaintenanceService service.ILeaderboardMaintenanceService
+	featureFlagService            service.IFeatureFlagService
+}
+
+// newApplication connects to Postgres, Redis and Kafka, loads the dynamic
+// config and wires the services. Nothing runs in the background until start
+// is called. When building fails, whatever was opened is closed again.
+func newApplication(config *appconfig.Config) (*application, error) {
+	app := &application{config: config, lifecycle: lifecycle.New(), health: health.NewRegistry()}
+	if err := app.build(); err != nil {
+		app.stop()
+		return nil, err
+	}
+	return app, nil
+}
+
+func (app *application) build() error {
+	if err := app.setupTracing(); err != nil {
+		return err
+	}
+	app.setupDatabase()
+	if err := migrateOnStartup(app.config, app.database); err != nil {
+		return fmt.Errorf("database schema check failed: %w", err)
+	}
+	app.setupRedis()
+
+	if err := app.setupDynamicConfig(); err != nil {
+		return err
+	}
+	app.setupLogLevel()
+	if err := app.setupAuth(); err != nil {
+		return err
+	}
+	if err := app.setupProducer(); err != nil {
+		return err
+	}
+
+	app.setupServices()
+	return nil
+}
+
+// start starts the background components, like dynamic config polling.
+func (app *application) start(ctx context.Context) error {
+	return app.lifecycle.Start(ctx)
+}
+
+// stop stops the background components and then flushes pending Kafka
+// messages and closes the connections.
+func (app *application) stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := app.lifecycle.Stop(ctx); err != nil {
+		log.GetLogger().WithError(err).Warn("Application did not stop cleanly")
+	}
+}
+
+// runApplication builds and starts the application, runs fn and stops the
+// application once fn returns.
+func runApplication(cmd *cobra.Command, fn func(ctx context.Context, app *application) error) error {
+	app, err := newApplication(&appConfig)
+	if err != nil {
+		return err
+	}
+	defer app.stop()
+
+	if err := app.start(cmd.Context()); err != nil {
+		return err
+	}
+	return fn(cmd.Context(), app)
+}
+
+// setupTracing installs the tracer provider first, so it is shut down, and
+// flushes its spans, after every component that records them has stopped.
+func (app *application) setupTracing() error {
+	provider, err := tracing.NewTracerProvider(context.Background(), app.config)
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	if provider == nil {
+		return nil
+	}
+	app.lifecycle.Append(lifecycle.Hook{
+		Name: "tracer provider",
+		Stop: provider.Shutdown,
+	})
+	return nil
+}
+
+func (app *application) setupDatabase() {
+	app.database = connectDatabase(app.config)
+	app.lifecycle.Append(lifecycle.Hook{
+		Name: "postgres",
+		Stop: func(ctx context.Context) error {
+			if err := app.database.Close(); err != nil {
+				return err
+			}
+			log.GetLogger().Info("Postgres connection closed successfully")
+			return nil
+		},
+	})
+	app.health.Register(health.Check{
+		Name:     "postgres",
+		Timeout:  2 * time.Second,
+		Critical: true,
+		Check:    app.database.PingContext,
+	})
+}
+
+func (app *application) setupRedis() {
+	app.redisCl = connectRedis(app.config)
+	app.lifecycle.Append(lifecycle.Hook{
+		Name: "redis",
+		Stop: func(ctx context.Context) error {
+			return app.redisCl.Close()
+		},
+	})
+	app.health.Register(health.Check{
+		Name:     "redis",
+		Timeout:  time.Second,
+		Critical: true,
+		Check: func(ctx context.Context) error {
+			return app.redisCl.Ping(ctx).Err()
+		},
+	})
+}
+
+func (app *application) setupDynamicConfig() error {
+	configSources, err := appconfig.BuildConfigSources(app.config, app.redisCl, app.database)
+	if err != nil {
+		return fmt.Errorf("failed to set up dynamic config sources: %w", err)
+	}
+	dynamicConfigService, err := appconfig.NewDynamicConfigService(app.config, configSources,
+		appconfig.NewConfigSnapshotStore(app.config, app.redisCl))
+	if err != nil {
+		return fmt.Errorf("failed to load dynamic config: %w", err)
+	}
+	app.dynamicConfigService = dynamicConfigService
+	app.lifecycle.Append(lifecycle.Background("dynamic config", dynamicConfigService.Start))
+
+	// An invalid config would pay no rewards, while failing sources only mean
+	// the last loaded config is still in use.
+	app.health.Register(health.Check{
+		Name:     "dynamic config",
+		Critical: true,
+		Check: func(ctx context.Context) error {
+			return dynamicConfigService.GetConfig().Validate()
+		},
+	})
+	app.health.Register(health.Check{
+		Name: "dynamic config sources",
+		Check: func(ctx context.Context) error {
+			return dynamicConfigService.LoadError()
+		},
+	})
+	return nil
+}
+
+// setupLogLevel applies the logLevel of the dynamic config now and whenever
+// it changes.
+func (app *application) setupLogLevel() {
+	unsubscribe := app.dynamicConfigService.OnChange(func(old, new appconfig.DynamicConfig) {
+		if old.LogLevel == new.LogLevel {
+			return
+		}
+		if err := log.SetLevel(new.LogLevel); err != nil {
+			log.GetLogger().WithError(err).Warn("Keeping the current log level")
+			return
+		}
+		log.GetLogger().Infof("Log level changed to %s", new.LogLevel)
+	})
+	// Applied once the listener is registered, so a reload in between is not
+	// missed.
+	if err := log.SetLevel(app.dynamicConfigService.GetConfig().LogLevel); err != nil {
+		log.GetLogger().WithError(err).Warn("Keeping the current log level")
+	}
+	app.lifecycle.Append(lifecycle.Hook{
+		Name: "log level subscription",
+		Stop: func(ctx context.Context) error {
+			unsubscribe()
+			return nil
+		},
+	})
+}
+
+func (app *application) setupAuth() error {
+	keyring, err := setupKeyring(app.config)
+	if err != nil {
+		return fmt.Errorf("failed to load token keyring: %w", err)
+	}
+	if app.config.TokenKeyringFile != "" {
+		app.lifecycle.Append(lifecycle.Background("keyring watch", func(ctx context.Context) {
+			keyring.WatchFile(ctx, app.config.TokenKeyringFile, 30*time.Second)
+		}))
+	}
+	app.keyring = keyring
+	app.tokenAuth = auth.NewAuth(keyring, app.dynamicConfigService.GetConfig().AccessTokenTTL())
+	app.tokenDenylist = auth.NewRedisTokenDenylist(app.redisCl)
+	app.loginLockout = auth.NewRedisLoginLockout(app.redisCl)
+	app.rateLimiter = ratelimit.NewSlidingWindowLimiter(app.redisCl)
+
+	// The TTL is read again once the listener is registered, so a reload in
+	// between is not missed.
+	unsubscribe := app.dynamicConfigService.OnChange(func(old, new appconfig.DynamicConfig) {
+		if old.AccessTokenTTL() != new.AccessTokenTTL() {
+			app.tokenAuth.SetTokenTTL(new.AccessTokenTTL())
+			log.GetLogger().Infof("Access token TTL changed to %s", new.AccessTokenTTL())
+		}
+	})
+	app.tokenAuth.SetTokenTTL(app.dynamicConfigService.GetConfig().AccessTokenTTL())
+	app.lifecycle.Append(lifecycle.Hook{
+		Name: "access token TTL subscription",
+		Stop: func(ctx context.Context) error {
+			unsubscribe()
+			return nil
+		},
+	})
+
+	serviceSecrets, err := auth.ParseServiceSecrets(app.config.ServiceAuthSecrets)
+	if err != nil {
+		return fmt.Errorf("failed to parse service auth secrets: %w", err)
+	}
+	app.serviceAuth = auth.NewServiceAuthenticator(serviceSecrets, app.redisCl)
+	return nil
+}
+
+func (app *application) setupProducer() error {
+	kafkaConfig := &ckafka.ConfigMap{
+		"bootstrap.servers": app.config.KafkaBootstrapServers,
+		"security.protocol": app.config.KafkaSecurityProtocol,
+		"sasl.mechanisms":   app.config.KafkaSaslMechanism,
+		"sasl.username":     app.config.KafkaSaslUsername,
+		"sasl.password":     app.config.KafkaSaslPassword,
+		"client.id":         app.config.KafkaClientId,
+	}
+	kafkaProducer, err := producer.NewKafkaProducer(kafkaConfig)
+	if err != nil {
+		return err
+	}
+	app.producer = kafkaProducer.GetProducer()
+	app.lifecycle.Append(lifecycle.Hook{
+		Name: "kafka producer",
+		Stop: func(ctx context.Context) error {
+			if remaining := kafkaProducer.Close(5 * time.Second); remaining > 0 {
+				return fmt.Errorf("%d Kafka messages were not delivered before shutdown", remaining)
+			}
+			return nil
+		},
+	})
+
+	// Messages are queued while the brokers are unreachable, so the API can
+	// keep serving.
+	app.health.Register(health.Check{
+		Name:    "kafka",
+		Timeout: 3 * time.Second,
+		Check: func(ctx context.Context) error {
+			return producer.CheckBrokers(ctx, app.producer)
+		},
+	})
+	return nil
+}
+
+func (app *application) setupServices() {
+	// Repositories
+	app.userRepository = repository.NewUserRepository(app.database)
+	app.tournamentRepository = repository.NewTournamentRepository(app.database)
+	groupRepository := repository.NewGroupRepository(app.database)
+	app.tournamentUserRepository = repository.NewTournamentUserRepository(app.database)
+	tournamentRewardRepository := repository.NewTournamentRewardRepository(app.database)
+	app.leaderboardStore = repository.NewLeaderboardStore(app.redisCl)
+	refreshTokenRepository := repository.NewRefreshTokenRepository(app.database)
+
+	// Cache
+	app.leaderboardCache = setupLeaderboardCache(app.config, app.redisCl)
+
+	// Services
+	userProfileService := service.NewUserProfileService(app.redisCl, app.userRepository)
+	app.featureFlagService = service.NewFeatureFlagService(app.userRepository, app.dynamicConfigService)
+	app.userService = service.NewUserService(
+		app.userRepository, app.tournamentRepository, app.tournamentUserRepository,
+		userProfileService, app.loginLockout, app.dynamicConfigService, app.producer)
+	app.tokenService = service.NewTokenService(app.database, app.tokenAuth, refreshTokenRepository,
+		app.userRepository, app.tokenDenylist, app.dynamicConfigService)
+	app.tournamentService = service.NewTournamentService(app.database,
+		app.tournamentRepository, groupRepository, app.tournamentUserRepository,
+		app.userRepository, tournamentRewardRepository, app.dynamicConfigService,
+		app.producer)
+	app.leaderboardEventService = service.NewLeaderboardEventService(app.redisCl, app.leaderboardStore,
+		app.tournamentRepository, app.tournamentUserRepository)
+	app.leaderboardService = service.NewLeaderboardService(app.leaderboardStore, userProfileService,
+		app.leaderboardEventService, app.leaderboardCache)
+	app.leaderboardMaintenanceService = service.NewLeaderboardMaintenanceService(app.leaderboardStore,
+		app.userRepository, app.tournamentRepository, app.tournamentUserRepository, app.dynamicConfigService)
+}
+
+func connectDatabase(config *appconfig.Config) *bun.DB {
+	pgConnectionConfig := postgres.ConnectionConfig{
+		Env:          config.Env,
+		Host:         config.GoodBlastDBUrl,
+		Username:     config.PostgresUsername,
+		Password:     config.PostgresPassword,
+		DatabaseName: config.GoodBlastDBName,
+	}
+
+	log.GetLogger().Infof("Database connection established, database: %s", config.GoodBlastDBName)
+	return postgres.NewDatabaseConnection().Connect(pgConnectionConfig)
+}
+
+func connectRedis(config *appconfig.Config) *redis.Client {
+	redisCl := redisclient.NewClient(config)
+
+	log.GetLogger().Infof("Redis connection established on %s:%s", config.RedisHost, config.RedisPort)
+	return redisCl.Client
+}
+
+// setupKeyring loads the token keys from a keyring file, from inline JSON, or
+// falls back to TokenSecretKey alone.
+func setupKeyring(config *appconfig.Config) (*auth.Keyring, error) {
+	var keyringJSON []byte
+	switch {
+	case config.TokenKeyringFile != "":
+		data, err := os.ReadFile(config.TokenKeyringFile)
+		if err != nil {
+			return nil, err
+		}
+		keyringJSON = data
+	case config.TokenKeyring != "":
+		keyringJSON = []byte(config.TokenKeyring)
+	default:
+		return auth.NewSecretKeyring(config.TokenSecretKey)
+	}
+
+	keyringConfig, err := auth.ParseKeyringConfig(keyringJSON)
+	if err != nil {
+		return nil, err
+	}
+	keyring, err := auth.NewKeyring(keyringConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.TokenSecretKey != "" {
+		if err := keyring.SetLegacySecret(config.TokenSecretKey); err != nil {
+			return nil, err
+		}
+	}
+	return keyring, nil
+}
+
+func setupLeaderboardCache(config *appconfig.Config, redisCl *redis.Client) cache.Cache {
+	return cache.New(cache.Options{
+		Name:       "leaderboard",
+		Size:       10000,
+		LocalTTL:   10 * time.Second,
+		Redis:      redisCl,
+		SharedTier: config.CacheRedisTier,
+	})
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/uptrace/bun"
+	appconfig "goodblast/config"
+	"goodblast/db"
+	"goodblast/internal/infrastructure/postgres"
+	"goodblast/pkg/log"
+	"io/fs"
+	"os"
+	"strconv"
+	"text/tabwriter"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply, revert or list database migrations",
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply pending migrations",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withMigrator(func(migrator postgres.IMigrator) error {
+			applied, err := migrator.Up(cmd.Context())
+			if err != nil {
+				return err
+			}
+			log.GetLogger().Infof("Applied %d migrations, schema is at version %d", applied, migrator.Latest())
+			return nil
+		})
+	},
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down [steps]",
+	Short: "Revert the last applied migrations, one unless steps is given",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		steps := 1
+		if len(args) == 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[0]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[0])
+			}
+		}
+
+		return withMigrator(func(migrator postgres.IMigrator) error {
+			reverted, err := migrator.Down(cmd.Context(), steps)
+			if err != nil {
+				return err
+			}
+			log.GetLogger().Infof("Reverted %d migrations", reverted)
+			return nil
+		})
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "List migrations and the current schema version",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withMigrator(func(migrator postgres.IMigrator) error {
+			version, dirty, err := migrator.Version(cmd.Context())
+			if err != nil {
+				return err
+			}
+			statuses, err := migrator.Status(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintf(writer, "VERSION\tNAME\tAPPLIED\n")
+			for _, status := range statuses {
+				fmt.Fprintf(writer, "%d\t%s\t%t\n", status.Version, status.Name, status.Applied)
+			}
+			writer.Flush()
+			fmt.Printf("\nCurrent version: %d, dirty: %t, latest: %d\n", version, dirty, migrator.Latest())
+			return nil
+		})
+	},
+}
+
+func init() {
+	migrateCmd.AddCommand(migrateUpCmd, migrateDownCmd, migrateStatusCmd)
+}
+
+// withMigrator connects to Postgres only; migrating must not depend on Redis,
+// Kafka or a valid dynamic config.
+func withMigrator(fn func(migrator postgres.IMigrator) error) error {
+	database := connectDatabase(&appConfig)
+	defer database.Close()
+
+	migrator, err := setupMigrator(database)
+	if err != nil {
+		return err
+	}
+	return fn(migrator)
+}
+
+func setupMigrator(database *bun.DB) (postgres.IMigrator, error) {
+	migrations, err := fs.Sub(db.Migrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return postgres.NewMigrator(database, migrations)
+}
+
+// migrateOnStartup applies pending migrations when MigrateOnStartup is set
+// and then refuses to continue while the schema is behind this build.
+func migrateOnStartup(config *appconfig.Config, database *bun.DB) error {
+	migrator, err := setupMigrator(database)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	if config.MigrateOnStartup {
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		if applied > 0 {
+			log.GetLogger().Infof("Applied %d migrations on startup", applied)
+		}
+	}
+	return migrator.CheckSchema(ctx)
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"github.com/robfig/cron/v3"
+	"github.com/spf13/cobra"
+	"goodblast/internal/application/service"
+	"goodblast/pkg/log"
+	"time"
+)
+
+var schedulerCmd = &cobra.Command{
+	Use:   "scheduler",
+	Short: "Run scheduled jobs",
+	Long:  "Upgrades the leaderboard key schema when needed, then closes and opens the daily tournaments and reconciles the leaderboards on schedule until interrupted. Run a single scheduler per deployment.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runApplication(cmd, func(ctx context.Context, app *application) error {
+			return runMonitored(ctx, app, func(ctx context.Context) error {
+				runScheduler(ctx, app)
+				return nil
+			})
+		})
+	},
+}
+
+// runScheduler returns once ctx is cancelled and running jobs have finished.
+// The leaderboard schema upgrade runs beside the jobs, so a long rebuild does
+// not hold up closing or opening a tournament.
+func runScheduler(ctx context.Context, app *application) {
+	upgraded := make(chan struct{})
+	go func() {
+		defer close(upgraded)
+		if err := app.leaderboardMaintenanceService.UpgradeSchema(ctx); err != nil {
+			log.GetLogger().WithError(err).Error("Failed to upgrade the leaderboard schema")
+		}
+	}()
+
+	c := setupCronJobs(app.tournamentService, app.leaderboardMaintenanceService)
+	log.GetLogger().Info("Scheduler started")
+
+	<-ctx.Done()
+	<-c.Stop().Done()
+	<-upgraded
+	log.GetLogger().Info("Scheduler stopped")
+}
+
+func setupCronJobs(
+	tournamentService service.ITournamentService,
+	leaderboardMaintenanceService service.ILeaderboardMaintenanceService,
+) *cron.Cron {
+	c := cron.New(cron.WithLocation(time.UTC))
+
+	c.AddFunc("59 23 * * *", func() {
+		activeTournament, err := tournamentService.GetActiveTournament(context.Background())
+		if err != nil {
+			log.GetLogger().WithError(err).Error("Failed to fetch active tournament")
+			return
+		}
+		if activeTournament == nil {
+			log.GetLogger().Warn("No active tournament found at 23:59.")
+			return
+		}
+
+		err = tournamentService.CloseTournament(context.Background(), activeTournament.ID)
+		if err != nil {
+			log.GetLogger().WithError(err).Error("Failed to close tournament")
+			return
+		}
+		log.GetLogger().Info("Daily tournament closed at 23:59 UTC.")
+		// todo: distribute rewards
+	})
+
+	c.AddFunc("0 0 * * *", func() {
+		_, err := createAndStartNewTournament(context.Background(), tournamentService)
+		if err != nil {
+			log.GetLogger().WithError(err).Error("Failed to create/start new tournament at 00:00")
+			return
+		}
+		log.GetLogger().Info("New daily tournament created & started at 00:00 UTC.")
+	})
+
+	c.AddFunc("*/10 * * * *", func() {
+		_, err := leaderboardMaintenanceService.ReconcileWithConfig(context.Background())
+		if err != nil {
+			log.GetLogger().WithError(err).Error("Failed to reconcile leaderboards")
+		}
+	})
+
+	c.Start()
+	return c
+}
+
+func createAndStartNewTournament(ctx context.Context, tournamentService service.ITournamentService) (int64, error) {
+	tournament, err := tournamentService.CreateDailyTournament(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tournamentService.StartTournament(ctx, tournament.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	return tournament.ID, nil
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/cobra"
+	swaggerfiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"golang.org/x/sync/errgroup"
+	appconfig "goodblast/config"
+	"goodblast/docs"
+	"goodblast/internal/application/controller"
+	"goodblast/internal/domain/entity"
+	"goodblast/internal/middleware"
+	"goodblast/internal/validation"
+	"goodblast/pkg/log"
+	"goodblast/pkg/server"
+	"net/http"
+	"strings"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the HTTP API",
+	Long:  "Serves the HTTP API, the config webhook and the leaderboard streams. Kafka consumers and scheduled jobs run in the worker and scheduler commands.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runApplication(cmd, runServer)
+	},
+}
+
+func runServer(ctx context.Context, app *application) error {
+	go app.leaderboardEventService.Run(ctx)
+	go app.leaderboardCache.Listen(ctx)
+
+	log.GetLogger().Info("Starting GoodBlast API...")
+	return server.NewServer(newRouter(app)).StartHTTPServer(ctx, app.config)
+}
+
+func newRouter(app *application) *gin.Engine {
+	setupSwaggerInfo(*app.config)
+
+	engine := setupGinEngine(app.config)
+
+	if app.config.Env != "prod" {
+		setupSwagger(engine, app.config)
+	}
+
+	middleware.LivenessHealthCheckMiddleware(engine)
+	middleware.ReadinessHealthCheckMiddleware(engine, app.health)
+
+	engine.POST("/webhook", app.dynamicConfigService.WebhookHandler)
+
+	validator := validation.NewRequestValidator()
+
+	// Controllers
+	authController := controller.NewAuthController(app.keyring)
+	userController := controller.NewUserController(app.userService, app.tokenService, validator)
+	tournamentController := controller.NewTournamentController(app.tournamentService, validator)
+	leaderBoardController := controller.NewLeaderboardController(app.leaderboardService, app.leaderboardMaintenanceService,
+		app.leaderboardEventService, validator)
+	featureFlagController := controller.NewFeatureFlagController(app.featureFlagService)
+
+	// Endpoints
+	engine.GET("/.well-known/paseto-keys", authController.GetPublicKeys)
+
+	authMiddleware := middleware.AuthMiddleware(app.tokenAuth, app.tokenDenylist, app.serviceAuth)
+	rateLimitMiddleware := middleware.RateLimitMiddleware(app.rateLimiter, app.dynamicConfigService)
+
+	// Public
+	public := engine.Group("/internal", rateLimitMiddleware)
+	public.POST("/user", userController.CreateUser)
+	public.POST("/user/login", userController.Login)
+	public.POST("/user/token/refresh", userController.RefreshToken)
+	public.GET("/tournament/active", tournamentController.GetActiveTournament)
+	public.GET("/leaderboard/global", leaderBoardController.GetLeaderboard)
+	public.GET("/leaderboard/country/:country", leaderBoardController.GetLeaderboard)
+	public.GET("/leaderboard/tournament/:tournamentId", leaderBoardController.GetLeaderboard)
+	public.GET("/leaderboard/tournament/:tournamentId/group/:groupId", leaderBoardController.GetLeaderboard)
+	public.GET("/leaderboard/user/:userId", leaderBoardController.GetUserRank)
+
+	// Player
+	player := engine.Group("/internal", authMiddleware, middleware.RequireRole(entity.UserRolePlayer, entity.UserRoleAdmin),
+		rateLimitMiddleware)
+	player.POST("/user/progress", userController.UpdateProgress)
+	player.POST("/user/logout", userController.Logout)
+	player.POST("/tournament/enter", tournamentController.EnterTournament)
+	player.POST("/tournament/reward/claim", tournamentController.ClaimReward)
+	player.GET("/leaderboard/global/me", leaderBoardController.GetMyStanding)
+	player.GET("/leaderboard/global/around-me", leaderBoardController.GetAroundMe)
+	player.GET("/leaderboard/country/:country/me", leaderBoardController.GetMyStanding)
+	player.GET("/leaderboard/country/:country/around-me", leaderBoardController.GetAroundMe)
+	player.GET("/leaderboard/tournament/:tournamentId/me", leaderBoardController.GetMyStanding)
+	player.GET("/leaderboard/tournament/:tournamentId/around-me", leaderBoardController.GetAroundMe)
+	player.GET("/leaderboard/stream/group", leaderBoardController.StreamLeaderboard)
+	player.GET("/leaderboard/stream/country/:country", leaderBoardController.StreamLeaderboard)
+
+	// Admin and backend services
+	admin := engine.Group("/internal", authMiddleware, middleware.RequireRole(entity.UserRoleAdmin, entity.UserRoleService))
+	admin.POST("/tournament/create-daily", tournamentController.CreateDailyTournament)
+	admin.POST("/tournament/start", tournamentController.StartTournament)
+	admin.POST("/tournament/close", tournamentController.CloseTournament)
+	admin.POST("/leaderboard/rebuild", leaderBoardController.RebuildLeaderboards)
+	admin.POST("/leaderboard/reconcile", leaderBoardController.ReconcileLeaderboards)
+	admin.GET("/flags/user/:userId", featureFlagController.GetUserFlags)
+
+	return engine
+}
+
+func setupSwaggerInfo(config appconfig.Config) {
+	docs.SwaggerInfo.Title = "GoodBlast API"
+	docs.SwaggerInfo.Description = "GoodBlast API documentation."
+	docs.SwaggerInfo.Version = "v1"
+	docs.SwaggerInfo.Host = config.SwaggerBaseUrl
+	docs.SwaggerInfo.Schemes = []string{"http", "https"}
+}
+
+func setupGinEngine(config *appconfig.Config) *gin.Engine {
+	engine := gin.New()
+	// Without trusted proxies Gin would believe X-Forwarded-For from anyone,
+	// letting clients pick the IP they are rate limited by.
+	var proxies []string
+	if config.TrustedProxies != "" {
+		for _, proxy := range strings.Split(config.TrustedProxies, ",") {
+			proxies = append(proxies, strings.TrimSpace(proxy))
+		}
+	}
+	if err := engine.SetTrustedProxies(proxies); err != nil {
+		log.GetLogger().WithError(err).Warn("Ignoring invalid TrustedProxies")
+		_ = engine.SetTrustedProxies(nil)
+	}
+	engine.Use(middleware.TracingMiddleware())
+	engine.Use(middleware.CorrelationIdMiddleware)
+	engine.Use(middleware.AccessLogMiddleware())
+	engine.Use(middleware.MetricsMiddleware())
+	engine.Use(middleware.ErrorHandlerMiddleware())
+	middleware.HealthCheckMiddleware(engine)
+	engine.Use(gin.Recovery())
+	engine.GET("/_monitoring/prometheus", gin.WrapH(promhttp.Handler()))
+	engine.NoRoute(middleware.NoRouteHandler)
+	return engine
+}
+
+// newMonitoringRouter serves only the health check and metrics endpoints, for
+// commands that do not serve the API.
+func newMonitoringRouter(app *application) *gin.Engine {
+	engine := gin.New()
+	engine.Use(gin.Recovery())
+	middleware.HealthCheckMiddleware(engine)
+	middleware.LivenessHealthCheckMiddleware(engine)
+	middleware.ReadinessHealthCheckMiddleware(engine, app.health)
+	engine.GET("/_monitoring/prometheus", gin.WrapH(promhttp.Handler()))
+	return engine
+}
+
+// runMonitored runs fn next to a monitoring server on the configured port, so
+// workers and the scheduler can be scraped and probed. It returns once both
+// have stopped.
+func runMonitored(ctx context.Context, app *application, fn func(ctx context.Context) error) error {
+	group, ctx := errgroup.WithContext(ctx)
+	group.Go(func() error {
+		return server.NewServer(newMonitoringRouter(app)).StartHTTPServer(ctx, app.config)
+	})
+	group.Go(func() error {
+		return fn(ctx)
+	})
+	return group.Wait()
+}
+
+func setupSwagger(engine *gin.Engine, config *appconfig.Config) {
+	engine.GET("/swagger/*any", basicAuthForSwagger(config), ginSwagger.WrapHandler(swaggerfiles.Handler))
+	engine.GET("/", func(ctx *gin.Context) {
+		ctx.Redirect(http.StatusMovedPermanently, ctx.Request.URL.Host+"/swagger/index.html")
+	})
+}
+
+func basicAuthForSwagger(config *appconfig.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, password, hasAuth := c.Request.BasicAuth()
+		if hasAuth && user == config.SwaggerUsername && password == config.SwaggerPassword {
+			c.Next()
+		} else {
+			c.Writer.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	ckafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/spf13/cobra"
+	appconfig "goodblast/config"
+	"goodblast/internal/infrastructure/kafka/consumer"
+	"goodblast/pkg/log"
+	"strings"
+	"sync"
+)
+
+const (
+	consumerTournamentEntry   = "tournament-entry"
+	consumerLeaderboardUpdate = "leaderboard-update"
+	consumerProgressUpdate    = "progress-update"
+)
+
+var allConsumers = []string{consumerTournamentEntry, consumerLeaderboardUpdate, consumerProgressUpdate}
+
+var workerConsumers []string
+
+var workerCmd = &cobra.Command{
+	Use:   "worker",
+	Short: "Run Kafka consumers",
+	Long:  "Runs the Kafka consumers named in --consumers until interrupted: " + strings.Join(allConsumers, ", ") + ".",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateConsumers(workerConsumers); err != nil {
+			return err
+		}
+		return runApplication(cmd, func(ctx context.Context, app *application) error {
+			return runMonitored(ctx, app, func(ctx context.Context) error {
+				return runWorkers(ctx, app, workerConsumers)
+			})
+		})
+	},
+}
+
+func init() {
+	workerCmd.Flags().StringSliceVar(&workerConsumers, "consumers", allConsumers, "consumers to run")
+}
+
+func validateConsumers(names []string) error {
+	for _, name := range names {
+		known := false
+		for _, consumerName := range allConsumers {
+			known = known || name == consumerName
+		}
+		if !known {
+			return fmt.Errorf("unknown consumer %q, expected one of %s", name, strings.Join(allConsumers, ", "))
+		}
+	}
+	return nil
+}
+
+// runWorkers runs each consumer on its own goroutine and returns once ctx is
+// cancelled and every consumer has stopped. The consumers follow topic changes
+// in the dynamic config until ctx is cancelled.
+func runWorkers(ctx context.Context, app *application, names []string) error {
+	consumerConfig := &ckafka.ConfigMap{
+		"bootstrap.servers":  app.config.KafkaBootstrapServers,
+		"security.protocol":  app.config.KafkaSecurityProtocol,
+		"sasl.mechanisms":    app.config.KafkaSaslMechanism,
+		"sasl.username":      app.config.KafkaSaslUsername,
+		"sasl.password":      app.config.KafkaSaslPassword,
+		"client.id":          app.config.KafkaClientId,
+		"session.timeout.ms": app.config.KafkaSessionTimeout,
+		"group.id":           app.config.KafkaConsumerGroupId,
+		"auto.offset.reset":  app.config.KafkaAutoOffsetReset,
+	}
+
+	var unsubscribes []func()
+	var wg sync.WaitGroup
+	for _, name := range names {
+		switch name {
+		case consumerTournamentEntry:
+			kafkaConsumer, err := ckafka.NewConsumer(consumerConfig)
+			if err != nil {
+				log.GetLogger().WithError(err).Error("Failed to create Kafka consumer")
+				continue
+			}
+			tournamentEntryConsumer := consumer.NewTournamentEntryConsumer(kafkaConsumer, app.tournamentService)
+			topic, unsubscribe := watchTopic(app, func(config appconfig.DynamicConfig) string {
+				return config.TournamentEntryTopic
+			}, tournamentEntryConsumer.SetTopic)
+			unsubscribes = append(unsubscribes, unsubscribe)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := tournamentEntryConsumer.StartConsume(ctx, topic); err != nil {
+					log.GetLogger().WithError(err).Error("Failed to start Kafka consumer")
+				}
+			}()
+
+		case consumerLeaderboardUpdate:
+			leaderBoardConsumer := consumer.NewLeaderboardConsumer(
+				app.leaderboardStore,
+				app.leaderboardEventService,
+				consumerConfig,
+			)
+			topic, unsubscribe := watchTopic(app, func(config appconfig.DynamicConfig) string {
+				return config.LeaderboardUpdateTopic
+			}, leaderBoardConsumer.SetTopic)
+			unsubscribes = append(unsubscribes, unsubscribe)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				leaderBoardConsumer.StartLeaderboardUpdateConsumer(ctx, topic)
+			}()
+
+		case consumerProgressUpdate:
+			progressUpdateConsumer := consumer.NewProgressUpdateConsumer(
+				app.tournamentService,
+				app.leaderboardService,
+				consumerConfig,
+			)
+			topic, unsubscribe := watchTopic(app, func(config appconfig.DynamicConfig) string {
+				return config.UserProgressUpdateTopic
+			}, progressUpdateConsumer.SetTopic)
+			unsubscribes = append(unsubscribes, unsubscribe)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				progressUpdateConsumer.StartProgressUpdateConsumer(ctx, topic)
+			}()
+		}
+		log.GetLogger().Infof("Kafka consumer %s started", name)
+	}
+
+	<-ctx.Done()
+	for _, unsubscribe := range unsubscribes {
+		unsubscribe()
+	}
+	wg.Wait()
+	log.GetLogger().Info("Kafka consumers stopped")
+	return nil
+}
+
+// watchTopic passes every change of the topic picked by topicOf to setTopic
+// and returns the current topic. The listener is registered before the topic
+// is read, so a reload in between is not missed; at worst setTopic is called
+// with the topic the consumer starts on, which only resubscribes it.
+func watchTopic(app *application, topicOf func(appconfig.DynamicConfig) string, setTopic func(string)) (string, func()) {
+	unsubscribe := app.dynamicConfigService.OnChange(func(old, new appconfig.DynamicConfig) {
+		if topicOf(old) != topicOf(new) {
+			setTopic(topicOf(new))
+		}
+	})
+	return topicOf(app.dynamicConfigService.GetConfig()), unsubscribe
+}
@@ -9,6 +9,8 @@ import (
 	"reflect"
 )
 
+const redacted = "[REDACTED]"
+
 type Config struct {
 	AppName string
 	Env     string
@@ -17,24 +19,50 @@ type Config struct {
 	// Swagger configuration
 	SwaggerBaseUrl  string
 	SwaggerUsername string
-	SwaggerPassword string
+	SwaggerPassword string `secret:"true"`
 
 	// Postgres configuration
 	GoodBlastDBUrl   string
 	GoodBlastDBName  string
 	PostgresUsername string
-	PostgresPassword string
-
-	TokenSecretKey  string
-	ToggleConfigURL string
-	GithubToken     string
+	PostgresPassword string `secret:"true"`
+
+	TokenSecretKey  string `optional:"true" secret:"true"`
+	ToggleConfigURL string `optional:"true"`
+	GithubToken     string `optional:"true" secret:"true"`
+	// GithubWebhookSecret verifies the X-Hub-Signature-256 header of config
+	// reload webhooks. Webhooks are rejected while it is empty.
+	GithubWebhookSecret string `optional:"true" secret:"true"`
+
+	// DynamicConfigSources lists the dynamic config sources, separated by
+	// commas, from lowest to highest precedence: file, redis, postgres and
+	// github. Keys set by a later source override earlier ones.
+	DynamicConfigSources  string `optional:"true"`
+	DynamicConfigFile     string `optional:"true"`
+	DynamicConfigRedisKey string `optional:"true"`
+	// DynamicConfigSnapshotFile is where the last valid dynamic config is
+	// kept for cold starts. It is kept in Redis when empty.
+	DynamicConfigSnapshotFile string `optional:"true"`
+	// DynamicConfigPollSeconds is how often every source is reloaded in case
+	// a webhook or file event was missed. Zero disables polling.
+	DynamicConfigPollSeconds int
+
+	// TokenKeyring is an inline JSON keyring and TokenKeyringFile the path of
+	// a keyring file that is reloaded when it changes. Without either,
+	// TokenSecretKey is used as the only key.
+	TokenKeyring     string `optional:"true" secret:"true"`
+	TokenKeyringFile string `optional:"true"`
+
+	// ServiceAuthSecrets holds "service=secret" pairs, separated by commas,
+	// that backend services sign admin requests with.
+	ServiceAuthSecrets string `optional:"true" secret:"true"`
 
 	// Kafka configuration
 	KafkaBootstrapServers string
 	KafkaSecurityProtocol string
 	KafkaSaslMechanism    string
 	KafkaSaslUsername     string
-	KafkaSaslPassword     string
+	KafkaSaslPassword     string `secret:"true"`
 	KafkaClientId         string
 	KafkaSessionTimeout   string
 	KafkaConsumerGroupId  string
@@ -43,9 +71,37 @@ type Config struct {
 	// Redis configuration
 	RedisHost               string
 	RedisPort               string
-	RedisPassword           string
+	RedisPassword           string `secret:"true"`
 	RedisDB                 int
 	RedisConnectionProtocol int
+
+	// MigrateOnStartup applies pending database migrations before serving.
+	// Without it the application refuses to start on an outdated schema.
+	MigrateOnStartup bool
+
+	// LogFormat is json or text. Logs are JSON when it is empty and Env is
+	// prod, and colored text otherwise.
+	LogFormat string `optional:"true"`
+
+	// TracingExporter is none, stdout or otlp. TracingOTLPEndpoint and
+	// TracingOTLPProtocol, grpc or http/protobuf, default to the standard
+	// OTEL_EXPORTER_OTLP_* variables. TracingSampleRatio is the share of
+	// traces started here that are recorded; traces continued from a caller
+	// follow the caller's decision.
+	TracingExporter     string `optional:"true"`
+	TracingOTLPEndpoint string `optional:"true"`
+	TracingOTLPProtocol string `optional:"true"`
+	TracingOTLPInsecure bool
+	TracingSampleRatio  float64
+
+	// TrustedProxies lists, separated by commas, the proxy addresses or CIDRs
+	// whose X-Forwarded-For header is believed when rate limiting by client
+	// IP. No proxy is trusted while it is empty.
+	TrustedProxies string `optional:"true"`
+
+	// CacheRedisTier shares cached API responses between instances through
+	// Redis instead of keeping them only in process memory.
+	CacheRedisTier bool
 }
 
 func LoadConfig() (Config, error) {
@@ -69,17 +125,39 @@ func LoadConfig() (Config, error) {
 	if err := validateConfig(config); err != nil {
 		return Config{}, err
 	}
+	if config.TokenSecretKey == "" && config.TokenKeyring == "" && config.TokenKeyringFile == "" {
+		return Config{}, errors.New("missing required configuration: TokenSecretKey, TokenKeyring or TokenKeyringFile")
+	}
+
+	if err := validateConfigSources(config); err != nil {
+		return Config{}, err
+	}
 
-	// print the configuration
-	logrus.Info(fmt.Sprintf("Configuration loaded successfully : %#v", config))
+	logrus.Infof("Configuration loaded successfully : %#v", config.Redacted())
 
 	return config, nil
 }
 
+// Redacted returns a copy of the config that is safe to log, with the value of
+// every field tagged secret replaced.
+func (c Config) Redacted() Config {
+	v := reflect.ValueOf(&c).Elem()
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		if t.Field(i).Tag.Get("secret") == "true" && v.Field(i).String() != "" {
+			v.Field(i).SetString(redacted)
+		}
+	}
+	return c
+}
+
 func validateConfig(cfg Config) error {
 	v := reflect.ValueOf(cfg)
 	t := reflect.TypeOf(cfg)
 	for i := 0; i < v.NumField(); i++ {
+		if t.Field(i).Tag.Get("optional") == "true" {
+			continue
+		}
 		if v.Field(i).Interface() == "" {
 			return errors.New("missing required configuration: " + t.Field(i).Name)
 		}
@@ -87,6 +165,29 @@ func validateConfig(cfg Config) error {
 	return nil
 }
 
+func validateConfigSources(cfg Config) error {
+	names := ParseConfigSourceNames(cfg.DynamicConfigSources)
+	if len(names) == 0 {
+		return errors.New("missing required configuration: DynamicConfigSources")
+	}
+	for _, name := range names {
+		switch name {
+		case ConfigSourceGithub:
+			if cfg.ToggleConfigURL == "" || cfg.GithubToken == "" {
+				return errors.New("missing required configuration: ToggleConfigURL and GithubToken for the github config source")
+			}
+		case ConfigSourceFile:
+			if cfg.DynamicConfigFile == "" {
+				return errors.New("missing required configuration: DynamicConfigFile for the file config source")
+			}
+		case ConfigSourceRedis, ConfigSourcePostgres:
+		default:
+			return fmt.Errorf("unknown dynamic config source %q", name)
+		}
+	}
+	return nil
+}
+
 func setDefaults() {
 	viper.SetDefault("AppName", viper.BindEnv("AppName"))
 	viper.SetDefault("Env", viper.BindEnv("Env"))
@@ -99,8 +200,17 @@ func setDefaults() {
 	viper.SetDefault("PostgresUsername", viper.BindEnv("PostgresUsername"))
 	viper.SetDefault("PostgresPassword", viper.BindEnv("PostgresPassword"))
 	viper.SetDefault("TokenSecretKey", viper.BindEnv("TokenSecretKey"))
+	viper.SetDefault("TokenKeyring", "")
+	viper.SetDefault("TokenKeyringFile", "")
+	viper.SetDefault("ServiceAuthSecrets", "")
 	viper.SetDefault("ToggleConfigURL", viper.BindEnv("ToggleConfigURL"))
 	viper.SetDefault("GithubToken", viper.BindEnv("GithubToken"))
+	viper.SetDefault("GithubWebhookSecret", "")
+	viper.SetDefault("DynamicConfigSources", ConfigSourceGithub)
+	viper.SetDefault("DynamicConfigFile", "")
+	viper.SetDefault("DynamicConfigRedisKey", DefaultConfigRedisKey)
+	viper.SetDefault("DynamicConfigSnapshotFile", "")
+	viper.SetDefault("DynamicConfigPollSeconds", 60)
 	viper.SetDefault("KafkaBootstrapServers", viper.BindEnv("KafkaBootstrapServers"))
 	viper.SetDefault("KafkaSecurityProtocol", viper.BindEnv("KafkaSecurityProtocol"))
 	viper.SetDefault("KafkaSaslMechanism", viper.BindEnv("KafkaSaslMechanism"))
@@ -115,4 +225,12 @@ func setDefaults() {
 	viper.SetDefault("RedisPassword", viper.BindEnv("RedisPassword"))
 	viper.SetDefault("RedisDB", viper.BindEnv("RedisDB"))
 	viper.SetDefault("RedisConnectionProtocol", viper.BindEnv("RedisConnectionProtocol"))
+	viper.SetDefault("MigrateOnStartup", false)
+	viper.SetDefault("CacheRedisTier", false)
+	viper.SetDefault("LogFormat", "")
+	viper.SetDefault("TracingExporter", "none")
+	viper.SetDefault("TracingOTLPEndpoint", "")
+	viper.SetDefault("TracingOTLPProtocol", "grpc")
+	viper.SetDefault("TracingOTLPInsecure", false)
+	viper.SetDefault("TracingSampleRatio", 1.0)
 }
@@ -0,0 +1,105 @@
+package appconfig
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"os"
+	"path/filepath"
+)
+
+const DefaultConfigSnapshotRedisKey = "config:dynamic:last-known-good"
+
+// ConfigSnapshotStore keeps the last config that passed validation so that an
+// instance starting while its sources are unreachable or invalid still runs
+// with real values. Load returns nil when no snapshot was saved yet.
+type ConfigSnapshotStore interface {
+	Save(ctx context.Context, config DynamicConfig) error
+	Load(ctx context.Context) (*DynamicConfig, error)
+}
+
+// NewConfigSnapshotStore stores the snapshot in DynamicConfigSnapshotFile when
+// set and in Redis otherwise.
+func NewConfigSnapshotStore(config *Config, redisCl *redis.Client) ConfigSnapshotStore {
+	if config.DynamicConfigSnapshotFile != "" {
+		return &fileConfigSnapshotStore{path: config.DynamicConfigSnapshotFile}
+	}
+	return &redisConfigSnapshotStore{client: redisCl, key: DefaultConfigSnapshotRedisKey}
+}
+
+type fileConfigSnapshotStore struct {
+	path string
+}
+
+// Save writes to a temporary file and renames it so a crash never leaves a
+// truncated snapshot behind.
+func (s *fileConfigSnapshotStore) Save(ctx context.Context, config DynamicConfig) error {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func (s *fileConfigSnapshotStore) Load(ctx context.Context) (*DynamicConfig, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeConfigSnapshot(data)
+}
+
+type redisConfigSnapshotStore struct {
+	client *redis.Client
+	key    string
+}
+
+func (s *redisConfigSnapshotStore) Save(ctx context.Context, config DynamicConfig) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, s.key, data, 0).Err()
+}
+
+func (s *redisConfigSnapshotStore) Load(ctx context.Context) (*DynamicConfig, error) {
+	data, err := s.client.Get(ctx, s.key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeConfigSnapshot(data)
+}
+
+// decodeConfigSnapshot validates the snapshot as well, since it may have been
+// written by an older release with looser rules.
+func decodeConfigSnapshot(data []byte) (*DynamicConfig, error) {
+	config := DefaultDynamicConfig()
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
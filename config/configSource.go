@@ -0,0 +1,109 @@
+package appconfig
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"github.com/uptrace/bun"
+	"strings"
+)
+
+const (
+	ConfigSourceFile     = "file"
+	ConfigSourceRedis    = "redis"
+	ConfigSourcePostgres = "postgres"
+	ConfigSourceGithub   = "github"
+)
+
+// ConfigSource provides one layer of the dynamic config. Load returns the
+// keys the layer sets, by their JSON names, and an empty layer when the
+// source holds no config.
+type ConfigSource interface {
+	Name() string
+	Load(ctx context.Context) (map[string]json.RawMessage, error)
+}
+
+// WatchableConfigSource is implemented by sources that can report changes as
+// they happen. Watch calls changed after every change until ctx is cancelled.
+type WatchableConfigSource interface {
+	ConfigSource
+	Watch(ctx context.Context, changed func()) error
+}
+
+// BuildConfigSources creates the sources named in DynamicConfigSources, in
+// order of increasing precedence.
+func BuildConfigSources(config *Config, redisCl *redis.Client, db *bun.DB) ([]ConfigSource, error) {
+	var sources []ConfigSource
+	for _, name := range ParseConfigSourceNames(config.DynamicConfigSources) {
+		switch name {
+		case ConfigSourceFile:
+			sources = append(sources, NewFileConfigSource(config.DynamicConfigFile))
+		case ConfigSourceRedis:
+			sources = append(sources, NewRedisConfigSource(redisCl, config.DynamicConfigRedisKey))
+		case ConfigSourcePostgres:
+			sources = append(sources, NewPostgresConfigSource(db))
+		case ConfigSourceGithub:
+			sources = append(sources, NewGithubConfigSource(config.ToggleConfigURL, config.GithubToken))
+		default:
+			return nil, fmt.Errorf("unknown dynamic config source %q", name)
+		}
+	}
+	return sources, nil
+}
+
+func ParseConfigSourceNames(raw string) []string {
+	var names []string
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// mergeConfigLayers loads every source and lets later layers override the
+// keys set by earlier ones, on top of DefaultDynamicConfig. A failing source
+// or an invalid result fails the whole load so the current config stays in
+// use.
+func mergeConfigLayers(ctx context.Context, sources []ConfigSource) (DynamicConfig, error) {
+	merged := make(map[string]json.RawMessage)
+	for _, source := range sources {
+		layer, err := source.Load(ctx)
+		if err != nil {
+			return DynamicConfig{}, fmt.Errorf("%s config source: %w", source.Name(), err)
+		}
+		for key, value := range layer {
+			merged[key] = value
+		}
+	}
+
+	if missing := missingRequiredKeys(merged); len(missing) > 0 {
+		return DynamicConfig{}, fmt.Errorf("invalid dynamic config: missing required keys %s", strings.Join(missing, ", "))
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return DynamicConfig{}, err
+	}
+	config := DefaultDynamicConfig()
+	if err := json.Unmarshal(data, &config); err != nil {
+		return DynamicConfig{}, fmt.Errorf("invalid dynamic config: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return DynamicConfig{}, err
+	}
+	return config, nil
+}
+
+func parseConfigLayer(data []byte) (map[string]json.RawMessage, error) {
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil, nil
+	}
+	var layer map[string]json.RawMessage
+	if err := json.Unmarshal(data, &layer); err != nil {
+		return nil, fmt.Errorf("config must be a JSON object: %w", err)
+	}
+	return layer, nil
+}
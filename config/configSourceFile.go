@@ -0,0 +1,84 @@
+package appconfig
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileConfigSource reads a local JSON or YAML file, chosen by extension. A
+// missing file is an empty layer.
+type FileConfigSource struct {
+	path string
+}
+
+func NewFileConfigSource(path string) *FileConfigSource {
+	return &FileConfigSource{path: filepath.Clean(path)}
+}
+
+func (s *FileConfigSource) Name() string {
+	return ConfigSourceFile
+}
+
+func (s *FileConfigSource) Load(ctx context.Context) (map[string]json.RawMessage, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(s.path)) {
+	case ".yaml", ".yml":
+		var values map[string]interface{}
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return nil, fmt.Errorf("invalid YAML in %s: %w", s.path, err)
+		}
+		if data, err = json.Marshal(values); err != nil {
+			return nil, err
+		}
+	}
+	return parseConfigLayer(data)
+}
+
+// Watch watches the directory rather than the file so that editors and
+// config management tools that replace the file by renaming are noticed.
+// Watcher errors, like a dropped event, are logged and watching goes on.
+func (s *FileConfigSource) Watch(ctx context.Context, changed func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(filepath.Dir(s.path)); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) == s.path && !event.Has(fsnotify.Chmod) {
+				changed()
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			logrus.Warnf("Error watching config file %s: %v", s.path, err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package appconfig
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// GithubConfigSource fetches a JSON file through the GitHub contents API.
+type GithubConfigSource struct {
+	url    string
+	token  string
+	client http.Client
+}
+
+func NewGithubConfigSource(url, token string) *GithubConfigSource {
+	return &GithubConfigSource{
+		url:    url,
+		token:  token,
+		client: http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *GithubConfigSource) Name() string {
+	return ConfigSourceGithub
+}
+
+func (s *GithubConfigSource) Load(ctx context.Context) (map[string]json.RawMessage, error) {
+	urlWithTimestamp := fmt.Sprintf("%s?t=%d", s.url, time.Now().UnixNano())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", urlWithTimestamp, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	req.Header.Set("Accept", "application/vnd.github.v3+json") // GitHub API v3 formatı
+	req.Header.Set("Cache-Control", "no-store, no-cache, must-revalidate")
+	req.Header.Set("Pragma", "no-cache")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch config from GitHub: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch config from GitHub, status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GitHub config response: %v", err)
+	}
+
+	type GitHubContentResponse struct {
+		Content string `json:"content"`
+	}
+
+	var githubResponse GitHubContentResponse
+	if err := json.Unmarshal(body, &githubResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub API response: %v", err)
+	}
+
+	decodedContent, err := base64.StdEncoding.DecodeString(githubResponse.Content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode Base64 content: %v", err)
+	}
+
+	return parseConfigLayer(decodedContent)
+}
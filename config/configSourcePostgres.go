@@ -0,0 +1,41 @@
+package appconfig
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/uptrace/bun"
+)
+
+type dynamicConfigRow struct {
+	bun.BaseModel `bun:"table:dynamic_config"`
+
+	Key   string          `bun:"key,pk"`
+	Value json.RawMessage `bun:"value,type:jsonb"`
+}
+
+// PostgresConfigSource reads the dynamic_config table, one row per key with
+// the value stored as JSON.
+type PostgresConfigSource struct {
+	db *bun.DB
+}
+
+func NewPostgresConfigSource(db *bun.DB) *PostgresConfigSource {
+	return &PostgresConfigSource{db: db}
+}
+
+func (s *PostgresConfigSource) Name() string {
+	return ConfigSourcePostgres
+}
+
+func (s *PostgresConfigSource) Load(ctx context.Context) (map[string]json.RawMessage, error) {
+	var rows []dynamicConfigRow
+	if err := s.db.NewSelect().Model(&rows).Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	layer := make(map[string]json.RawMessage, len(rows))
+	for _, row := range rows {
+		layer[row.Key] = row.Value
+	}
+	return layer, nil
+}
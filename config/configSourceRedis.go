@@ -0,0 +1,39 @@
+package appconfig
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/redis/go-redis/v9"
+)
+
+const DefaultConfigRedisKey = "config:dynamic"
+
+// RedisConfigSource reads a JSON object stored as a string at key. A missing
+// key is an empty layer.
+type RedisConfigSource struct {
+	client *redis.Client
+	key    string
+}
+
+func NewRedisConfigSource(client *redis.Client, key string) *RedisConfigSource {
+	if key == "" {
+		key = DefaultConfigRedisKey
+	}
+	return &RedisConfigSource{client: client, key: key}
+}
+
+func (s *RedisConfigSource) Name() string {
+	return ConfigSourceRedis
+}
+
+func (s *RedisConfigSource) Load(ctx context.Context) (map[string]json.RawMessage, error) {
+	data, err := s.client.Get(ctx, s.key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseConfigLayer(data)
+}
@@ -1,128 +1,278 @@
 package appconfig
 
 import (
-	"encoding/base64"
-	"encoding/json"
+	"context"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
-	"io"
-	"net/http"
+	"reflect"
 	"sync"
 	"time"
 )
 
+// configLoadTimeout bounds a single load of all sources.
+const configLoadTimeout = 15 * time.Second
+
 type ConfigKey string
 
+// DynamicConfig is validated before it is applied, see Validate. Keys listed
+// in requiredConfigKeys must be set by a source; the rest fall back to
+// DefaultDynamicConfig.
 type DynamicConfig struct {
-	TournamentCutoffHour        int    `json:"tournamentCutoffHour"`
-	MinimumTournamentEntryLevel int    `json:"minimumTournamentEntryLevel"`
-	TournamentEntranceCoins     int    `json:"tournamentEntranceCoins"`
-	Reward1                     int    `json:"reward1"`
-	Reward2                     int    `json:"reward2"`
-	Reward3                     int    `json:"reward3"`
-	Reward4to10                 int    `json:"reward4to10"`
-	CoinPerLevel                int    `json:"coinPerLevel"`
-	TokenTTL                    int    `json:"tokenTTL"`
-	TournamentEntryTopic        string `json:"tournamentEntryTopic"`
-	UserProgressUpdateTopic     string `json:"userProgressUpdateTopic"`
-	LeaderboardUpdateTopic      string `json:"leaderboardUpdateTopic"`
+	TournamentCutoffHour        int    `json:"tournamentCutoffHour" validate:"min=0,max=23"`
+	MinimumTournamentEntryLevel int    `json:"minimumTournamentEntryLevel" validate:"min=0"`
+	TournamentEntranceCoins     int    `json:"tournamentEntranceCoins" validate:"min=0"`
+	Reward1                     int    `json:"reward1" validate:"min=1"`
+	Reward2                     int    `json:"reward2" validate:"min=1,ltefield=Reward1"`
+	Reward3                     int    `json:"reward3" validate:"min=1,ltefield=Reward2"`
+	Reward4to10                 int    `json:"reward4to10" validate:"min=1,ltefield=Reward3"`
+	CoinPerLevel                int    `json:"coinPerLevel" validate:"min=1"`
+	TokenTTL                    int    `json:"tokenTTL" validate:"min=1,max=720"`
+	AccessTokenTTLMinutes       int    `json:"accessTokenTTLMinutes" validate:"min=1,max=1440"`
+	TournamentEntryTopic        string `json:"tournamentEntryTopic" validate:"kafkatopic"`
+	UserProgressUpdateTopic     string `json:"userProgressUpdateTopic" validate:"kafkatopic"`
+	LeaderboardUpdateTopic      string `json:"leaderboardUpdateTopic" validate:"kafkatopic"`
+	ReconcileSampleSize         int    `json:"reconcileSampleSize" validate:"min=1,max=100000"`
+	ReconcileAutoFix            bool   `json:"reconcileAutoFix"`
+	LogLevel                    string `json:"logLevel" validate:"oneof=trace debug info warn error"`
+
+	Flags        map[string]FeatureFlag     `json:"flags,omitempty" validate:"dive"`
+	RateLimits   map[string]RateLimitPolicy `json:"rateLimits,omitempty" validate:"dive"`
+	LoginLockout LoginLockout               `json:"loginLockout"`
+}
+
+// MaxAccessTokenTTL is the longest access token lifetime the validate tag of
+// AccessTokenTTLMinutes allows. The TTL can change at runtime, so anything that
+// must outlive every unexpired token uses this rather than the current TTL.
+const MaxAccessTokenTTL = 1440 * time.Minute
+
+// AccessTokenTTL is the lifetime of access tokens, 15 minutes unless
+// configured. TokenTTL, in hours, is the lifetime of refresh tokens.
+func (c DynamicConfig) AccessTokenTTL() time.Duration {
+	if c.AccessTokenTTLMinutes <= 0 {
+		return 15 * time.Minute
+	}
+	return time.Duration(c.AccessTokenTTLMinutes) * time.Minute
+}
+
+func (c DynamicConfig) RefreshTokenTTL() time.Duration {
+	return time.Duration(c.TokenTTL) * time.Hour
 }
 
 type IDynamicConfigService interface {
 	Initialize() error
 	GetConfig() DynamicConfig
+	LoadError() error
+	Reload(ctx context.Context) (map[string]ConfigChange, error)
+	Start(ctx context.Context)
+	OnChange(listener ConfigChangeListener) (unsubscribe func())
+	IsEnabled(ctx context.Context, flag string, user FlagUser) bool
+	Variant(ctx context.Context, flag string, user FlagUser) string
+	EvaluateFlags(ctx context.Context, user FlagUser) []FlagEvaluation
 	WebhookHandler(ctx *gin.Context)
 }
 
+// ConfigChangeListener is called after a reload replaced the config. It runs
+// on the reloading goroutine, so it must not block or call Reload.
+type ConfigChangeListener func(old, new DynamicConfig)
+
+// DynamicConfigService merges the configured sources into one DynamicConfig.
+// Sources are layered in order, each overriding the keys set by the ones
+// before it. Changes are picked up from the GitHub webhook, from sources that
+// can be watched and, as a fallback, by polling every source.
 type DynamicConfigService struct {
-	config           DynamicConfig
-	dynamicConfigURL string
-	githubToken      string
-	mutex            sync.RWMutex
-}
+	config       DynamicConfig
+	loadErr      error
+	sources      []ConfigSource
+	pollInterval time.Duration
+	mutex        sync.RWMutex
 
-var (
-	dynamicConfigInstance *DynamicConfigService
-	dynamicConfigOnce     sync.Once
-)
+	snapshot      ConfigSnapshotStore
+	savedSnapshot *DynamicConfig
 
-func GetDynamicConfigService(config *Config) *DynamicConfigService {
-	dynamicConfigOnce.Do(func() {
-		dynamicConfigInstance = &DynamicConfigService{
-			dynamicConfigURL: config.ToggleConfigURL,
-			githubToken:      config.GithubToken,
-		}
-		err := dynamicConfigInstance.Initialize()
-		if err != nil {
-			logrus.Errorf("Failed to initialize DynamicConfigService: %v", err)
-		}
-	})
-	return dynamicConfigInstance
-}
+	listenersMutex sync.RWMutex
+	listeners      map[int]ConfigChangeListener
+	nextListenerID int
 
-func (f *DynamicConfigService) Initialize() error {
-	return f.loadConfigFromGitHub()
+	webhookSecret []byte
+	configPath    string
+	reloadMutex   sync.Mutex
+	lastReload    time.Time
+	pendingReload *time.Timer
 }
 
-func (f *DynamicConfigService) loadConfigFromGitHub() error {
-	client := http.Client{
-		Timeout: 10 * time.Second,
+// NewDynamicConfigService loads the config before returning. It fails when
+// neither the sources nor the last-known-good snapshot yield a valid config;
+// running on zero values would pay no rewards and produce to empty topic names.
+func NewDynamicConfigService(config *Config, sources []ConfigSource, snapshot ConfigSnapshotStore) (*DynamicConfigService, error) {
+	service := &DynamicConfigService{
+		sources:       sources,
+		pollInterval:  time.Duration(config.DynamicConfigPollSeconds) * time.Second,
+		snapshot:      snapshot,
+		webhookSecret: []byte(config.GithubWebhookSecret),
+		configPath:    configPathFromURL(config.ToggleConfigURL),
+	}
+	if err := service.Initialize(); err != nil {
+		return nil, err
 	}
+	return service, nil
+}
+
+func (f *DynamicConfigService) Initialize() error {
+	ctx, cancel := context.WithTimeout(context.Background(), configLoadTimeout)
+	defer cancel()
+
+	f.reloadMutex.Lock()
+	defer f.reloadMutex.Unlock()
 
-	urlWithTimestamp := fmt.Sprintf("%s?t=%d", f.dynamicConfigURL, time.Now().UnixNano())
+	f.lastReload = time.Now()
+	loadErr := f.load(ctx)
+	if loadErr == nil {
+		logrus.Infof("Loaded dynamic config from %s: %+v", f.sourceNames(), f.GetConfig())
+		return nil
+	}
 
-	req, err := http.NewRequest("GET", urlWithTimestamp, nil)
+	snapshot, err := f.snapshot.Load(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %v", err)
+		return fmt.Errorf("%w, and the last-known-good snapshot is unusable: %v", loadErr, err)
+	}
+	if snapshot == nil {
+		return fmt.Errorf("%w, and there is no last-known-good snapshot", loadErr)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+f.githubToken)
-	req.Header.Set("Accept", "application/vnd.github.v3+json") // GitHub API v3 formatı
-	req.Header.Set("Cache-Control", "no-store, no-cache, must-revalidate")
-	req.Header.Set("Pragma", "no-cache")
+	f.mutex.Lock()
+	f.config = *snapshot
+	f.mutex.Unlock()
+	f.savedSnapshot = snapshot
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("could not fetch config from GitHub: %v", err)
+	logrus.Warnf("Starting with the last-known-good dynamic config, loading from %s failed: %v", f.sourceNames(), loadErr)
+	return nil
+}
+
+// Reload loads every source now, returning the keys that changed.
+func (f *DynamicConfigService) Reload(ctx context.Context) (map[string]ConfigChange, error) {
+	f.reloadMutex.Lock()
+	defer f.reloadMutex.Unlock()
+	return f.reload(ctx)
+}
+
+// Start watches the sources that support it and polls all of them every
+// DynamicConfigPollSeconds until ctx is cancelled.
+func (f *DynamicConfigService) Start(ctx context.Context) {
+	for _, source := range f.sources {
+		if watchable, ok := source.(WatchableConfigSource); ok {
+			go f.watch(ctx, watchable)
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to fetch config from GitHub, status code: %d", resp.StatusCode)
+	if f.pollInterval <= 0 {
+		return
 	}
+	ticker := time.NewTicker(f.pollInterval)
+	defer ticker.Stop()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read GitHub config response: %v", err)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		f.reloadInBackground(ctx, "poll")
 	}
+}
 
-	type GitHubContentResponse struct {
-		Content string `json:"content"`
+func (f *DynamicConfigService) watch(ctx context.Context, source WatchableConfigSource) {
+	err := source.Watch(ctx, func() {
+		f.reloadInBackground(ctx, source.Name()+" watch")
+	})
+	if err == nil {
+		return
+	}
+	if f.pollInterval > 0 {
+		logrus.Errorf("Stopped watching %s config source, falling back to polling: %v", source.Name(), err)
+	} else {
+		logrus.Errorf("Stopped watching %s config source, it is reloaded only by webhooks: %v", source.Name(), err)
 	}
+}
+
+func (f *DynamicConfigService) reloadInBackground(ctx context.Context, trigger string) {
+	loadCtx, cancel := context.WithTimeout(ctx, configLoadTimeout)
+	defer cancel()
 
-	var githubResponse GitHubContentResponse
-	if err := json.Unmarshal(body, &githubResponse); err != nil {
-		return fmt.Errorf("failed to parse GitHub API response: %v", err)
+	if _, err := f.Reload(loadCtx); err != nil {
+		logrus.Errorf("Failed to reload dynamic config on %s: %v", trigger, err)
 	}
+}
 
-	decodedContent, err := base64.StdEncoding.DecodeString(githubResponse.Content)
+// load must be called with reloadMutex held.
+func (f *DynamicConfigService) load(ctx context.Context) error {
+	config, err := mergeConfigLayers(ctx, f.sources)
+	f.mutex.Lock()
+	f.loadErr = err
+	if err == nil {
+		f.config = config
+	}
+	f.mutex.Unlock()
 	if err != nil {
-		return fmt.Errorf("failed to decode Base64 content: %v", err)
+		return err
 	}
 
-	var config DynamicConfig
-	if err := json.Unmarshal(decodedContent, &config); err != nil {
-		return fmt.Errorf("failed to parse JSON config from GitHub: %v", err)
+	f.saveSnapshot(ctx, config)
+	return nil
+}
+
+// saveSnapshot must be called with reloadMutex held. A failure only means a
+// cold start may fall back to an older snapshot, so it is logged.
+func (f *DynamicConfigService) saveSnapshot(ctx context.Context, config DynamicConfig) {
+	if f.savedSnapshot != nil && reflect.DeepEqual(*f.savedSnapshot, config) {
+		return
+	}
+	if err := f.snapshot.Save(ctx, config); err != nil {
+		logrus.Errorf("Failed to save the last-known-good dynamic config: %v", err)
+		return
 	}
+	f.savedSnapshot = &config
+}
 
-	f.mutex.Lock()
-	defer f.mutex.Unlock()
-	f.config = config
+func (f *DynamicConfigService) sourceNames() []string {
+	names := make([]string, 0, len(f.sources))
+	for _, source := range f.sources {
+		names = append(names, source.Name())
+	}
+	return names
+}
 
-	logrus.Infof("Successfully updated dynamic config from GitHub: %+v", f.config)
+// OnChange registers listener for every reload that changes at least one
+// key. Values read once at startup should be re-applied from here.
+func (f *DynamicConfigService) OnChange(listener ConfigChangeListener) (unsubscribe func()) {
+	f.listenersMutex.Lock()
+	defer f.listenersMutex.Unlock()
 
-	return nil
+	if f.listeners == nil {
+		f.listeners = make(map[int]ConfigChangeListener)
+	}
+	id := f.nextListenerID
+	f.nextListenerID++
+	f.listeners[id] = listener
+
+	return func() {
+		f.listenersMutex.Lock()
+		defer f.listenersMutex.Unlock()
+		delete(f.listeners, id)
+	}
+}
+
+func (f *DynamicConfigService) notifyListeners(previous, current DynamicConfig) {
+	f.listenersMutex.RLock()
+	listeners := make([]ConfigChangeListener, 0, len(f.listeners))
+	for _, listener := range f.listeners {
+		listeners = append(listeners, listener)
+	}
+	f.listenersMutex.RUnlock()
+
+	for _, listener := range listeners {
+		listener(previous, current)
+	}
 }
 
 func (f *DynamicConfigService) GetConfig() DynamicConfig {
@@ -131,12 +281,10 @@ func (f *DynamicConfigService) GetConfig() DynamicConfig {
 	return f.config
 }
 
-func (f *DynamicConfigService) WebhookHandler(ctx *gin.Context) {
-	logrus.Info("Received webhook request to update dynamic config")
-	if err := f.loadConfigFromGitHub(); err != nil {
-		logrus.Errorf("Failed to reload config from GitHub: %v", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reload config"})
-		return
-	}
-	ctx.JSON(http.StatusOK, gin.H{"message": "Configuration updated successfully"})
+// LoadError returns why the last load from the sources failed, or nil when it
+// succeeded. While it is set the service keeps running on the previous config.
+func (f *DynamicConfigService) LoadError() error {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	return f.loadErr
 }
@@ -0,0 +1,111 @@
+package appconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// requiredConfigKeys have no safe default: rewards are paid out in coins and
+// topics must match the ones the Kafka cluster was provisioned with.
+var requiredConfigKeys = []string{
+	"reward1",
+	"reward2",
+	"reward3",
+	"reward4to10",
+	"tournamentEntryTopic",
+	"userProgressUpdateTopic",
+	"leaderboardUpdateTopic",
+}
+
+var kafkaTopicPattern = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,249}$`)
+
+var configValidator = newConfigValidator()
+
+func DefaultDynamicConfig() DynamicConfig {
+	return DynamicConfig{
+		TournamentCutoffHour:        23,
+		MinimumTournamentEntryLevel: 10,
+		TournamentEntranceCoins:     500,
+		CoinPerLevel:                100,
+		TokenTTL:                    24,
+		AccessTokenTTLMinutes:       15,
+		ReconcileSampleSize:         500,
+		LogLevel:                    "info",
+		RateLimits:                  defaultRateLimits(),
+		LoginLockout:                defaultLoginLockout(),
+	}
+}
+
+// Validate checks every value against the ranges in the validate tags. The
+// error lists all invalid keys by their JSON names.
+func (c DynamicConfig) Validate() error {
+	err := configValidator.Struct(c)
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err
+	}
+
+	problems := make([]string, 0, len(validationErrors))
+	for _, fieldError := range validationErrors {
+		problems = append(problems, describeFieldError(fieldError))
+	}
+	return fmt.Errorf("invalid dynamic config: %s", strings.Join(problems, "; "))
+}
+
+func missingRequiredKeys(layer map[string]json.RawMessage) []string {
+	var missing []string
+	for _, key := range requiredConfigKeys {
+		if _, ok := layer[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+func describeFieldError(fieldError validator.FieldError) string {
+	field := strings.TrimPrefix(fieldError.Namespace(), "DynamicConfig.")
+	switch fieldError.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case "min":
+		return fmt.Sprintf("%s must be at least %s, got %v", field, fieldError.Param(), fieldError.Value())
+	case "max":
+		return fmt.Sprintf("%s must be at most %s, got %v", field, fieldError.Param(), fieldError.Value())
+	case "ltefield":
+		return fmt.Sprintf("%s must not exceed %s, got %v", field, jsonFieldName(fieldError.Param()), fieldError.Value())
+	case "gtefield":
+		// Nested structs compare fields named like their lower-camel JSON keys.
+		param := fieldError.Param()
+		return fmt.Sprintf("%s must be at least %s, got %v", field, strings.ToLower(param[:1])+param[1:], fieldError.Value())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of %s, got %q", field, fieldError.Param(), fieldError.Value())
+	case "kafkatopic":
+		return fmt.Sprintf("%s must be a Kafka topic name, got %q", field, fieldError.Value())
+	default:
+		return fmt.Sprintf("%s failed %s validation", field, fieldError.Tag())
+	}
+}
+
+func jsonFieldName(structField string) string {
+	field, ok := reflect.TypeOf(DynamicConfig{}).FieldByName(structField)
+	if !ok {
+		return structField
+	}
+	return strings.Split(field.Tag.Get("json"), ",")[0]
+}
+
+func newConfigValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return strings.Split(field.Tag.Get("json"), ",")[0]
+	})
+	_ = v.RegisterValidation("kafkatopic", func(fl validator.FieldLevel) bool {
+		return kafkaTopicPattern.MatchString(fl.Field().String())
+	})
+	return v
+}
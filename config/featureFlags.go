@@ -0,0 +1,166 @@
+package appconfig
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	FlagReasonUnknown      = "unknown-flag"
+	FlagReasonDisabled     = "disabled"
+	FlagReasonAllowList    = "allow-list"
+	FlagReasonCountry      = "country-not-targeted"
+	FlagReasonLevel        = "level-not-targeted"
+	FlagReasonRollout      = "rollout"
+	FlagReasonNotInRollout = "not-in-rollout"
+)
+
+// FeatureFlag is a boolean flag, or a variant flag when Variants is set. A
+// user gets the flag when it is enabled and the user is on the allow-list, or
+// matches the country and level targeting and falls inside the rollout
+// percentage. Users are bucketed by a hash of the flag name and user ID, so a
+// user keeps the same answer as the percentage grows.
+type FeatureFlag struct {
+	Enabled      bool     `json:"enabled"`
+	AllowUserIDs []int64  `json:"allowUserIds,omitempty"`
+	Countries    []string `json:"countries,omitempty"`
+	MinLevel     int      `json:"minLevel,omitempty" validate:"min=0"`
+	// MaxLevel of zero means no upper bound.
+	MaxLevel int `json:"maxLevel,omitempty" validate:"min=0"`
+	// Percentage of targeted users that get the flag, 100 when omitted.
+	Percentage *int          `json:"percentage,omitempty" validate:"omitempty,min=0,max=100"`
+	Variants   []FlagVariant `json:"variants,omitempty" validate:"dive"`
+}
+
+// FlagVariant is picked for enabled users in proportion to its weight.
+type FlagVariant struct {
+	Name   string `json:"name" validate:"required"`
+	Weight int    `json:"weight" validate:"min=1"`
+}
+
+// FlagUser holds the attributes flags can target.
+type FlagUser struct {
+	ID      int64
+	Country string
+	Level   int
+}
+
+type FlagEvaluation struct {
+	Flag    string `json:"flag"`
+	Enabled bool   `json:"enabled"`
+	Variant string `json:"variant,omitempty"`
+	Reason  string `json:"reason"`
+}
+
+func (f *DynamicConfigService) IsEnabled(ctx context.Context, flag string, user FlagUser) bool {
+	return f.EvaluateFlag(ctx, flag, user).Enabled
+}
+
+// Variant returns the variant user is assigned to, or "" when the flag is off
+// for the user or has no variants.
+func (f *DynamicConfigService) Variant(ctx context.Context, flag string, user FlagUser) string {
+	return f.EvaluateFlag(ctx, flag, user).Variant
+}
+
+func (f *DynamicConfigService) EvaluateFlag(ctx context.Context, flag string, user FlagUser) FlagEvaluation {
+	definition, ok := f.GetConfig().Flags[flag]
+	if !ok {
+		return FlagEvaluation{Flag: flag, Reason: FlagReasonUnknown}
+	}
+	return definition.Evaluate(flag, user)
+}
+
+// EvaluateFlags evaluates every flag for user, sorted by flag name.
+func (f *DynamicConfigService) EvaluateFlags(ctx context.Context, user FlagUser) []FlagEvaluation {
+	flags := f.GetConfig().Flags
+	evaluations := make([]FlagEvaluation, 0, len(flags))
+	for name, definition := range flags {
+		evaluations = append(evaluations, definition.Evaluate(name, user))
+	}
+	sort.Slice(evaluations, func(i, j int) bool { return evaluations[i].Flag < evaluations[j].Flag })
+	return evaluations
+}
+
+func (ff FeatureFlag) Evaluate(name string, user FlagUser) FlagEvaluation {
+	evaluation := FlagEvaluation{Flag: name}
+	switch {
+	case !ff.Enabled:
+		evaluation.Reason = FlagReasonDisabled
+		return evaluation
+	case ff.allows(user.ID):
+		evaluation.Reason = FlagReasonAllowList
+	case !ff.targetsCountry(user.Country):
+		evaluation.Reason = FlagReasonCountry
+		return evaluation
+	case user.Level < ff.MinLevel || (ff.MaxLevel > 0 && user.Level > ff.MaxLevel):
+		evaluation.Reason = FlagReasonLevel
+		return evaluation
+	case flagBucket(name, "rollout", user.ID, 100) >= ff.percentage():
+		evaluation.Reason = FlagReasonNotInRollout
+		return evaluation
+	default:
+		evaluation.Reason = FlagReasonRollout
+	}
+
+	evaluation.Enabled = true
+	evaluation.Variant = ff.variantFor(name, user.ID)
+	return evaluation
+}
+
+func (ff FeatureFlag) allows(userID int64) bool {
+	for _, allowed := range ff.AllowUserIDs {
+		if allowed == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func (ff FeatureFlag) targetsCountry(country string) bool {
+	if len(ff.Countries) == 0 {
+		return true
+	}
+	for _, targeted := range ff.Countries {
+		if strings.EqualFold(targeted, country) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ff FeatureFlag) percentage() int {
+	if ff.Percentage == nil {
+		return 100
+	}
+	return *ff.Percentage
+}
+
+// variantFor uses its own hash so that the variant split does not correlate
+// with who is inside the rollout.
+func (ff FeatureFlag) variantFor(name string, userID int64) string {
+	totalWeight := 0
+	for _, variant := range ff.Variants {
+		totalWeight += variant.Weight
+	}
+	if totalWeight == 0 {
+		return ""
+	}
+
+	bucket := flagBucket(name, "variant", userID, totalWeight)
+	for _, variant := range ff.Variants {
+		if bucket < variant.Weight {
+			return variant.Name
+		}
+		bucket -= variant.Weight
+	}
+	return ff.Variants[len(ff.Variants)-1].Name
+}
+
+func flagBucket(name, salt string, userID int64, buckets int) int {
+	sum := sha256.Sum256([]byte(name + ":" + salt + ":" + strconv.FormatInt(userID, 10)))
+	return int(binary.BigEndian.Uint64(sum[:8]) % uint64(buckets))
+}
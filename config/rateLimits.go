@@ -0,0 +1,114 @@
+package appconfig
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	RateLimitKeyIP    = "ip"
+	RateLimitKeyUser  = "user"
+	RateLimitKeyRoute = "route"
+)
+
+// RateLimitPolicy allows Limit requests to Route within any window of
+// WindowSeconds. Key selects what the requests are counted per: the client
+// IP, the authenticated user, falling back to the IP on public routes, or the
+// route as a whole. Policies are keyed by name in the dynamic config, so a
+// source can override or disable a default policy by its name.
+type RateLimitPolicy struct {
+	Route         string `json:"route" validate:"required"`
+	Method        string `json:"method,omitempty" validate:"omitempty,oneof=GET POST PUT PATCH DELETE"`
+	Key           string `json:"key" validate:"oneof=ip user route"`
+	Limit         int    `json:"limit" validate:"min=1"`
+	WindowSeconds int    `json:"windowSeconds" validate:"min=1,max=86400"`
+	Disabled      bool   `json:"disabled,omitempty"`
+}
+
+func (p RateLimitPolicy) Window() time.Duration {
+	return time.Duration(p.WindowSeconds) * time.Second
+}
+
+// Matches reports whether the policy applies to a request for the route
+// pattern, like "/internal/leaderboard/country/:country", and method.
+func (p RateLimitPolicy) Matches(route, method string) bool {
+	if p.Disabled || p.Route != route {
+		return false
+	}
+	return p.Method == "" || strings.EqualFold(p.Method, method)
+}
+
+// LoginLockout locks an account for one IP after MaxFailures failed logins
+// from it, and for every IP after MaxAccountFailures failed logins from any.
+// Each further failure doubles the lockout, starting at BaseLockoutSeconds and
+// capped at MaxLockoutSeconds. Failures are forgotten FailureWindowSeconds
+// after the last one; those from one IP also on a successful login from it.
+type LoginLockout struct {
+	Enabled              bool `json:"enabled"`
+	MaxFailures          int  `json:"maxFailures" validate:"min=1"`
+	MaxAccountFailures   int  `json:"maxAccountFailures" validate:"min=1,gtefield=MaxFailures"`
+	FailureWindowSeconds int  `json:"failureWindowSeconds" validate:"min=1,max=86400"`
+	BaseLockoutSeconds   int  `json:"baseLockoutSeconds" validate:"min=1"`
+	MaxLockoutSeconds    int  `json:"maxLockoutSeconds" validate:"min=1,gtefield=BaseLockoutSeconds"`
+}
+
+func (l LoginLockout) FailureWindow() time.Duration {
+	return time.Duration(l.FailureWindowSeconds) * time.Second
+}
+
+// LockoutFor returns how long the account is locked for an IP after the nth
+// failure from it.
+func (l LoginLockout) LockoutFor(failures int) time.Duration {
+	return l.backoff(failures, l.MaxFailures)
+}
+
+// AccountLockoutFor returns how long the account is locked for every IP after
+// the nth failure from any of them.
+func (l LoginLockout) AccountLockoutFor(failures int) time.Duration {
+	return l.backoff(failures, l.MaxAccountFailures)
+}
+
+func (l LoginLockout) backoff(failures, threshold int) time.Duration {
+	if failures < threshold {
+		return 0
+	}
+
+	lockout := time.Duration(l.BaseLockoutSeconds) * time.Second
+	maxLockout := time.Duration(l.MaxLockoutSeconds) * time.Second
+	for i := threshold; i < failures && lockout < maxLockout; i++ {
+		lockout *= 2
+	}
+	return min(lockout, maxLockout)
+}
+
+func defaultRateLimits() map[string]RateLimitPolicy {
+	return map[string]RateLimitPolicy{
+		"login-ip": {
+			Route: "/internal/user/login", Method: "POST", Key: RateLimitKeyIP,
+			Limit: 20, WindowSeconds: 60,
+		},
+		"register-ip": {
+			Route: "/internal/user", Method: "POST", Key: RateLimitKeyIP,
+			Limit: 10, WindowSeconds: 3600,
+		},
+		"refresh-ip": {
+			Route: "/internal/user/token/refresh", Method: "POST", Key: RateLimitKeyIP,
+			Limit: 30, WindowSeconds: 60,
+		},
+		"progress-user": {
+			Route: "/internal/user/progress", Method: "POST", Key: RateLimitKeyUser,
+			Limit: 30, WindowSeconds: 60,
+		},
+	}
+}
+
+func defaultLoginLockout() LoginLockout {
+	return LoginLockout{
+		Enabled:              true,
+		MaxFailures:          5,
+		MaxAccountFailures:   50,
+		FailureWindowSeconds: 900,
+		BaseLockoutSeconds:   30,
+		MaxLockoutSeconds:    3600,
+	}
+}
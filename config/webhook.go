@@ -0,0 +1,220 @@
+package appconfig
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	domain "goodblast/internal/domain/errors"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	githubEventHeader     = "X-GitHub-Event"
+	githubSignatureHeader = "X-Hub-Signature-256"
+	githubSignaturePrefix = "sha256="
+
+	// reloadDebounce is the minimum time between two webhook reloads.
+	// Pushes arriving sooner are coalesced into one trailing reload.
+	reloadDebounce = 10 * time.Second
+)
+
+type ConfigChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+type githubPushEvent struct {
+	Ref     string         `json:"ref"`
+	Commits []githubCommit `json:"commits"`
+}
+
+type githubCommit struct {
+	Added    []string `json:"added"`
+	Modified []string `json:"modified"`
+	Removed  []string `json:"removed"`
+}
+
+// WebhookHandler reloads the dynamic config when GitHub reports a push that
+// touches the config file. Requests must carry a valid X-Hub-Signature-256.
+func (f *DynamicConfigService) WebhookHandler(ctx *gin.Context) {
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		ctx.Error(domain.ErrInvalidRequest.WithDetail("unable to read request body"))
+		return
+	}
+
+	if len(f.webhookSecret) == 0 {
+		logrus.Warn("Rejected config webhook, GithubWebhookSecret is not configured")
+		ctx.Error(domain.ErrWebhookNotConfigured)
+		return
+	}
+	if !f.validSignature(ctx.GetHeader(githubSignatureHeader), body) {
+		logrus.Warnf("Rejected config webhook with an invalid signature from %s", ctx.ClientIP())
+		ctx.Error(domain.ErrInvalidSignature)
+		return
+	}
+
+	switch event := ctx.GetHeader(githubEventHeader); event {
+	case "ping":
+		ctx.JSON(http.StatusOK, gin.H{"message": "pong"})
+		return
+	case "push":
+	default:
+		ctx.JSON(http.StatusAccepted, gin.H{"message": "Ignored " + event + " event"})
+		return
+	}
+
+	var push githubPushEvent
+	if err := json.Unmarshal(body, &push); err != nil {
+		ctx.Error(domain.ErrInvalidRequest.WithDetail("invalid push payload"))
+		return
+	}
+	if !push.touches(f.configPath) {
+		ctx.JSON(http.StatusAccepted, gin.H{"message": "Ignored push, config file unchanged"})
+		return
+	}
+
+	logrus.Infof("Received push to %s touching %s, reloading dynamic config", push.Ref, f.configPath)
+
+	changes, reloaded, err := f.debouncedReload()
+	if err != nil {
+		logrus.Errorf("Failed to reload dynamic config: %v", err)
+		ctx.Error(domain.ErrInternalServerError.WithDetail("failed to reload config"))
+		return
+	}
+	if !reloaded {
+		ctx.JSON(http.StatusAccepted, gin.H{"message": "Reload scheduled"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Configuration updated successfully", "changes": changes})
+}
+
+func (f *DynamicConfigService) validSignature(header string, body []byte) bool {
+	if !strings.HasPrefix(header, githubSignaturePrefix) {
+		return false
+	}
+	signature, err := hex.DecodeString(strings.TrimPrefix(header, githubSignaturePrefix))
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, f.webhookSecret)
+	mac.Write(body)
+	return hmac.Equal(signature, mac.Sum(nil))
+}
+
+// debouncedReload fetches the config now unless it was fetched within the
+// debounce window, in which case one trailing reload is scheduled for the end
+// of the window and reloaded is false.
+func (f *DynamicConfigService) debouncedReload() (changes map[string]ConfigChange, reloaded bool, err error) {
+	f.reloadMutex.Lock()
+	defer f.reloadMutex.Unlock()
+
+	wait := reloadDebounce - time.Since(f.lastReload)
+	if wait > 0 {
+		if f.pendingReload == nil {
+			f.pendingReload = time.AfterFunc(wait, f.trailingReload)
+		}
+		return nil, false, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), configLoadTimeout)
+	defer cancel()
+
+	changes, err = f.reload(ctx)
+	return changes, err == nil, err
+}
+
+func (f *DynamicConfigService) trailingReload() {
+	f.reloadMutex.Lock()
+	defer f.reloadMutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), configLoadTimeout)
+	defer cancel()
+
+	f.pendingReload = nil
+	if _, err := f.reload(ctx); err != nil {
+		logrus.Errorf("Failed to reload dynamic config: %v", err)
+	}
+}
+
+// reload must be called with reloadMutex held.
+func (f *DynamicConfigService) reload(ctx context.Context) (map[string]ConfigChange, error) {
+	previous := f.GetConfig()
+	f.lastReload = time.Now()
+	if err := f.load(ctx); err != nil {
+		return nil, err
+	}
+
+	current := f.GetConfig()
+	changes := diffConfig(previous, current)
+	if len(changes) > 0 {
+		logrus.Infof("Dynamic config changed: %+v", changes)
+		f.notifyListeners(previous, current)
+	}
+	return changes, nil
+}
+
+// diffConfig returns the changed keys, named by their JSON field names.
+func diffConfig(previous, current DynamicConfig) map[string]ConfigChange {
+	changes := make(map[string]ConfigChange)
+	previousValue := reflect.ValueOf(previous)
+	currentValue := reflect.ValueOf(current)
+	configType := previousValue.Type()
+
+	for i := 0; i < configType.NumField(); i++ {
+		oldField := previousValue.Field(i).Interface()
+		newField := currentValue.Field(i).Interface()
+		if reflect.DeepEqual(oldField, newField) {
+			continue
+		}
+
+		name := strings.Split(configType.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = configType.Field(i).Name
+		}
+		changes[name] = ConfigChange{Old: oldField, New: newField}
+	}
+	return changes
+}
+
+func (e githubPushEvent) touches(path string) bool {
+	if path == "" {
+		return true
+	}
+	for _, commit := range e.Commits {
+		for _, files := range [][]string{commit.Added, commit.Modified, commit.Removed} {
+			for _, file := range files {
+				if file == path {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// configPathFromURL extracts the repository path of the config file from a
+// GitHub contents API URL such as
+// https://api.github.com/repos/<owner>/<repo>/contents/<path>.
+func configPathFromURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	_, path, found := strings.Cut(parsed.Path, "/contents/")
+	if !found {
+		return ""
+	}
+	return path
+}
@@ -0,0 +1,9 @@
+package db
+
+import "embed"
+
+// Migrations holds the golang-migrate style SQL migrations so the binary can
+// apply them without the source tree.
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
@@ -0,0 +1,2 @@
+ALTER TABLE users
+    ADD COLUMN role TEXT NOT NULL DEFAULT 'player' CHECK (role IN ('player', 'admin', 'service'));
\ No newline at end of file
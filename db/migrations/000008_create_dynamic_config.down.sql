@@ -0,0 +1 @@
+DROP TABLE dynamic_config
\ No newline at end of file
@@ -0,0 +1,6 @@
+CREATE TABLE dynamic_config
+(
+    key        TEXT PRIMARY KEY,
+    value      JSONB     NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
@@ -0,0 +1,2 @@
+ALTER TABLE tournament_rewards
+    DROP CONSTRAINT tournament_rewards_tournament_user_key;
\ No newline at end of file
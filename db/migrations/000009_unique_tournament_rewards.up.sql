@@ -0,0 +1,11 @@
+-- Keep one reward per user and tournament, preferring a claimed one, before
+-- making duplicates impossible.
+DELETE
+FROM tournament_rewards r
+    USING tournament_rewards o
+WHERE r.tournament_id = o.tournament_id
+  AND r.user_id = o.user_id
+  AND (o.claimed::int, -o.id) > (r.claimed::int, -r.id);
+
+ALTER TABLE tournament_rewards
+    ADD CONSTRAINT tournament_rewards_tournament_user_key UNIQUE (tournament_id, user_id);
@@ -15,6 +15,982 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/.well-known/paseto-keys": {
+            "get": {
+                "description": "Returns the Ed25519 public keys, by key ID, that other services can use to verify v4.public access tokens without the signing secret.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "List token verification keys",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.PublicKeysResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/flags/user/{userId}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ServiceSignature": []
+                    }
+                ],
+                "description": "Reports every feature flag as evaluated for the user, with the variant assigned and the reason for the result, for debugging rollouts.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Feature Flags"
+                ],
+                "summary": "Evaluate Feature Flags for a User",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.FeatureFlagsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/leaderboard/country/{country}": {
+            "get": {
+                "description": "Retrieves a page of the global, country or tournament leaderboard. Pass the returned next_cursor to fetch the following page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Leaderboard"
+                ],
+                "summary": "Get Leaderboard Page",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Country Code (e.g., US, TR, DE)",
+                        "name": "country",
+                        "in": "path"
+                    },
+                    {
+                        "enum": [
+                            "alltime",
+                            "daily",
+                            "weekly"
+                        ],
+                        "type": "string",
+                        "description": "Time window for global and country boards",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Zero-based offset, ignored when cursor is set",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-1000, default 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.LeaderboardPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/leaderboard/country/{country}/around-me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves the players ranked directly above and below the authenticated user.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Leaderboard"
+                ],
+                "summary": "Get Leaderboard Around Me",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Country Code (e.g., US, TR, DE)",
+                        "name": "country",
+                        "in": "path"
+                    },
+                    {
+                        "enum": [
+                            "alltime",
+                            "daily",
+                            "weekly"
+                        ],
+                        "type": "string",
+                        "description": "Time window for global and country boards",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Players to return on each side (1-50, default 5)",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.LeaderboardPage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User is not ranked",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/leaderboard/country/{country}/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves the rank, score and percentile of the authenticated user.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Leaderboard"
+                ],
+                "summary": "Get My Leaderboard Standing",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Country Code (e.g., US, TR, DE)",
+                        "name": "country",
+                        "in": "path"
+                    },
+                    {
+                        "enum": [
+                            "alltime",
+                            "daily",
+                            "weekly"
+                        ],
+                        "type": "string",
+                        "description": "Time window for global and country boards",
+                        "name": "period",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.UserStandingResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User is not ranked",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/leaderboard/global": {
+            "get": {
+                "description": "Retrieves a page of the global, country or tournament leaderboard. Pass the returned next_cursor to fetch the following page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Leaderboard"
+                ],
+                "summary": "Get Leaderboard Page",
+                "parameters": [
+                    {
+                        "enum": [
+                            "alltime",
+                            "daily",
+                            "weekly"
+                        ],
+                        "type": "string",
+                        "description": "Time window for global and country boards",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Zero-based offset, ignored when cursor is set",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-1000, default 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.LeaderboardPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/leaderboard/global/around-me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves the players ranked directly above and below the authenticated user.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Leaderboard"
+                ],
+                "summary": "Get Leaderboard Around Me",
+                "parameters": [
+                    {
+                        "enum": [
+                            "alltime",
+                            "daily",
+                            "weekly"
+                        ],
+                        "type": "string",
+                        "description": "Time window for global and country boards",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Players to return on each side (1-50, default 5)",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.LeaderboardPage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User is not ranked",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/leaderboard/global/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves the rank, score and percentile of the authenticated user.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Leaderboard"
+                ],
+                "summary": "Get My Leaderboard Standing",
+                "parameters": [
+                    {
+                        "enum": [
+                            "alltime",
+                            "daily",
+                            "weekly"
+                        ],
+                        "type": "string",
+                        "description": "Time window for global and country boards",
+                        "name": "period",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.UserStandingResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User is not ranked",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/leaderboard/rebuild": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ServiceSignature": []
+                    }
+                ],
+                "description": "Repopulates the all-time global and country leaderboards and a tournament leaderboard from Postgres and swaps them in atomically.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Leaderboard"
+                ],
+                "summary": "Rebuild Leaderboards",
+                "parameters": [
+                    {
+                        "description": "Tournament board to rebuild (defaults to the active tournament)",
+                        "name": "requestBody",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/request.RebuildLeaderboardRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.LeaderboardRebuildResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin or service role required",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/leaderboard/reconcile": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ServiceSignature": []
+                    }
+                ],
+                "description": "Samples users from Postgres, reports score drift against Redis and optionally raises scores that fell behind.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Leaderboard"
+                ],
+                "summary": "Reconcile Leaderboards",
+                "parameters": [
+                    {
+                        "description": "Sample size and whether to fix drift",
+                        "name": "requestBody",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/request.ReconcileLeaderboardRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.LeaderboardReconcileReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin or service role required",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/leaderboard/stream/country/{country}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Opens a Server-Sent Events stream of rank changes on the authenticated user's tournament group or on a country board. Each \"rank\" event carries a LeaderboardRankChangedEvent; \"heartbeat\" events keep idle connections open.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Leaderboard"
+                ],
+                "summary": "Stream Leaderboard Rank Changes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Country Code (e.g., US, TR, DE)",
+                        "name": "country",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/events.LeaderboardRankChangedEvent"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No active tournament or user has not entered it",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/leaderboard/stream/group": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Opens a Server-Sent Events stream of rank changes on the authenticated user's tournament group or on a country board. Each \"rank\" event carries a LeaderboardRankChangedEvent; \"heartbeat\" events keep idle connections open.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Leaderboard"
+                ],
+                "summary": "Stream Leaderboard Rank Changes",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/events.LeaderboardRankChangedEvent"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No active tournament or user has not entered it",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/leaderboard/tournament/{tournamentId}": {
+            "get": {
+                "description": "Retrieves a page of the global, country or tournament leaderboard. Pass the returned next_cursor to fetch the following page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Leaderboard"
+                ],
+                "summary": "Get Leaderboard Page",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tournament ID",
+                        "name": "tournamentId",
+                        "in": "path"
+                    },
+                    {
+                        "enum": [
+                            "alltime",
+                            "daily",
+                            "weekly"
+                        ],
+                        "type": "string",
+                        "description": "Time window for global and country boards",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Zero-based offset, ignored when cursor is set",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-1000, default 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.LeaderboardPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/leaderboard/tournament/{tournamentId}/around-me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves the players ranked directly above and below the authenticated user.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Leaderboard"
+                ],
+                "summary": "Get Leaderboard Around Me",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tournament ID",
+                        "name": "tournamentId",
+                        "in": "path"
+                    },
+                    {
+                        "enum": [
+                            "alltime",
+                            "daily",
+                            "weekly"
+                        ],
+                        "type": "string",
+                        "description": "Time window for global and country boards",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Players to return on each side (1-50, default 5)",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.LeaderboardPage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User is not ranked",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/leaderboard/tournament/{tournamentId}/group/{groupId}": {
+            "get": {
+                "description": "Retrieves a page of the global, country or tournament leaderboard. Pass the returned next_cursor to fetch the following page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Leaderboard"
+                ],
+                "summary": "Get Leaderboard Page",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tournament ID",
+                        "name": "tournamentId",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Tournament group ID",
+                        "name": "groupId",
+                        "in": "path"
+                    },
+                    {
+                        "enum": [
+                            "alltime",
+                            "daily",
+                            "weekly"
+                        ],
+                        "type": "string",
+                        "description": "Time window for global and country boards",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Zero-based offset, ignored when cursor is set",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-1000, default 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.LeaderboardPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/leaderboard/tournament/{tournamentId}/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves the rank, score and percentile of the authenticated user.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Leaderboard"
+                ],
+                "summary": "Get My Leaderboard Standing",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tournament ID",
+                        "name": "tournamentId",
+                        "in": "path"
+                    },
+                    {
+                        "enum": [
+                            "alltime",
+                            "daily",
+                            "weekly"
+                        ],
+                        "type": "string",
+                        "description": "Time window for global and country boards",
+                        "name": "period",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.UserStandingResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User is not ranked",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/leaderboard/user/{userId}": {
+            "get": {
+                "description": "Retrieves the all-time global rank, score and percentile of a specific user.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Leaderboard"
+                ],
+                "summary": "Get User Rank",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.UserStandingResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/internal/tournament/active": {
             "get": {
                 "description": "Returns the tournament that is currently marked \"active\" and within time range.",
@@ -35,19 +1011,13 @@ const docTemplate = `{
                     "404": {
                         "description": "No active tournament found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     }
                 }
@@ -55,6 +1025,14 @@ const docTemplate = `{
         },
         "/internal/tournament/close": {
             "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ServiceSignature": []
+                    }
+                ],
                 "description": "Moves a tournament status to \"closed\".",
                 "consumes": [
                     "application/json"
@@ -87,28 +1065,31 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin or service role required",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     },
                     "409": {
                         "description": "Tournament Already Ended/Closed",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     }
                 }
@@ -116,6 +1097,14 @@ const docTemplate = `{
         },
         "/internal/tournament/create-daily": {
             "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ServiceSignature": []
+                    }
+                ],
                 "description": "Creates a \"planned\" tournament for the current day.",
                 "consumes": [
                     "application/json"
@@ -134,13 +1123,22 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.CreateDailyTournamentResponse"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin or service role required",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     }
                 }
@@ -174,46 +1172,31 @@ const docTemplate = `{
                     "401": {
                         "description": "Unauthorized or invalid user ID",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     },
                     "403": {
                         "description": "Forbidden if user level or coins are insufficient",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     },
                     "404": {
                         "description": "No active tournament found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     },
                     "409": {
                         "description": "Tournament already ended (if needed)",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     }
                 }
@@ -250,28 +1233,19 @@ const docTemplate = `{
                     "401": {
                         "description": "Unauthorized or invalid user ID",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     },
                     "404": {
                         "description": "No unclaimed rewards found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     },
                     "500": {
                         "description": "Server error or database failure",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     }
                 }
@@ -279,6 +1253,14 @@ const docTemplate = `{
         },
         "/internal/tournament/start": {
             "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ServiceSignature": []
+                    }
+                ],
                 "description": "Changes a tournament status from \"planned\" to \"active\" (if not ended).",
                 "consumes": [
                     "application/json"
@@ -311,28 +1293,31 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin or service role required",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     },
                     "409": {
                         "description": "Tournament Already Ended/Closed",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     }
                 }
@@ -371,13 +1356,13 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     }
                 }
@@ -416,31 +1401,32 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     }
                 }
             }
         },
-        "/internal/user/progress": {
+        "/internal/user/logout": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
+                "description": "Revokes the access token used for the request and the given refresh token. Set allSessions to revoke every token of the user.",
                 "consumes": [
                     "application/json"
                 ],
@@ -450,7 +1436,17 @@ const docTemplate = `{
                 "tags": [
                     "User Controller"
                 ],
-                "summary": "Update user progress",
+                "summary": "Logout user",
+                "parameters": [
+                    {
+                        "description": "Refresh token to revoke",
+                        "name": "requestBody",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/request.LogoutRequest"
+                        }
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK"
@@ -458,131 +1454,113 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
-                        }
-                    }
-                }
-            }
-        },
-        "/leaderboard/country/{country}": {
-            "get": {
-                "description": "Retrieves the top 1000 users from the leaderboard for a specific country.",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Leaderboard"
-                ],
-                "summary": "Get Country Leaderboard",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Country Code (e.g., US, TR, DE)",
-                        "name": "country",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/response.LeaderboardEntry"
-                            }
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     }
                 }
             }
         },
-        "/leaderboard/global": {
-            "get": {
-                "description": "Retrieves the top 1000 users from the global leaderboard.",
+        "/internal/user/progress": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Leaderboard"
+                    "User Controller"
                 ],
-                "summary": "Get Global Leaderboard",
+                "summary": "Update user progress",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/response.LeaderboardEntry"
-                            }
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     }
                 }
             }
         },
-        "/leaderboard/user/{userId}/rank": {
-            "get": {
-                "description": "Retrieves the global rank of a specific user.",
+        "/internal/user/token/refresh": {
+            "post": {
+                "description": "Exchanges a refresh token for a new access token and a new refresh token. Each refresh token can be used once; reusing one revokes the whole session.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Leaderboard"
+                    "User Controller"
                 ],
-                "summary": "Get User Rank",
+                "summary": "Refresh access token",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "User ID",
-                        "name": "userId",
-                        "in": "path",
-                        "required": true
+                        "description": "Refresh token returned by login or a previous refresh",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.RefreshTokenRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User Rank",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/response.UserLoginResponse"
                         }
                     },
-                    "404": {
-                        "description": "User Not Found",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ProblemResponse"
                         }
                     }
                 }
@@ -590,6 +1568,23 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "events.LeaderboardRankChangedEvent": {
+            "type": "object",
+            "properties": {
+                "board": {
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
         "request.CloseTournamentReq": {
             "type": "object",
             "required": [
@@ -597,7 +1592,8 @@ const docTemplate = `{
             ],
             "properties": {
                 "id": {
-                    "type": "integer"
+                    "type": "integer",
+                    "minimum": 1
                 }
             }
         },
@@ -620,6 +1616,51 @@ const docTemplate = `{
                 }
             }
         },
+        "request.LogoutRequest": {
+            "type": "object",
+            "properties": {
+                "allSessions": {
+                    "type": "boolean"
+                },
+                "refreshToken": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.RebuildLeaderboardRequest": {
+            "type": "object",
+            "properties": {
+                "tournamentId": {
+                    "description": "TournamentID selects the tournament board to rebuild; the active tournament is used when empty.",
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "request.ReconcileLeaderboardRequest": {
+            "type": "object",
+            "properties": {
+                "fix": {
+                    "type": "boolean"
+                },
+                "sampleSize": {
+                    "type": "integer",
+                    "maximum": 10000,
+                    "minimum": 1
+                }
+            }
+        },
+        "request.RefreshTokenRequest": {
+            "type": "object",
+            "required": [
+                "refreshToken"
+            ],
+            "properties": {
+                "refreshToken": {
+                    "type": "string"
+                }
+            }
+        },
         "request.StartTournamentReq": {
             "type": "object",
             "required": [
@@ -627,7 +1668,8 @@ const docTemplate = `{
             ],
             "properties": {
                 "id": {
-                    "type": "integer"
+                    "type": "integer",
+                    "minimum": 1
                 }
             }
         },
@@ -671,13 +1713,39 @@ const docTemplate = `{
                 }
             }
         },
-        "response.ErrorResponse": {
+        "response.FeatureFlagEvaluation": {
             "type": "object",
             "properties": {
-                "description": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "flag": {
                     "type": "string"
                 },
-                "status": {
+                "reason": {
+                    "type": "string"
+                },
+                "variant": {
+                    "type": "string"
+                }
+            }
+        },
+        "response.FeatureFlagsResponse": {
+            "type": "object",
+            "properties": {
+                "country": {
+                    "type": "string"
+                },
+                "flags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/response.FeatureFlagEvaluation"
+                    }
+                },
+                "level": {
+                    "type": "integer"
+                },
+                "userId": {
                     "type": "integer"
                 }
             }
@@ -699,9 +1767,33 @@ const docTemplate = `{
                 }
             }
         },
+        "response.LeaderboardDrift": {
+            "type": "object",
+            "properties": {
+                "actual": {
+                    "description": "Actual is nil when the user is missing from the sorted set.",
+                    "type": "integer"
+                },
+                "expected": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
         "response.LeaderboardEntry": {
             "type": "object",
             "properties": {
+                "country": {
+                    "type": "string"
+                },
+                "level": {
+                    "type": "integer"
+                },
                 "rank": {
                     "type": "integer"
                 },
@@ -710,6 +1802,135 @@ const docTemplate = `{
                 },
                 "user_id": {
                     "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "response.LeaderboardPage": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/response.LeaderboardEntry"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "response.LeaderboardRebuildResponse": {
+            "type": "object",
+            "properties": {
+                "countries": {
+                    "type": "integer"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "tournament_id": {
+                    "type": "integer"
+                },
+                "tournament_users": {
+                    "type": "integer"
+                },
+                "users": {
+                    "type": "integer"
+                }
+            }
+        },
+        "response.LeaderboardReconcileReport": {
+            "type": "object",
+            "properties": {
+                "drifted": {
+                    "type": "integer"
+                },
+                "drifts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/response.LeaderboardDrift"
+                    }
+                },
+                "fixed": {
+                    "type": "integer"
+                },
+                "sampled": {
+                    "type": "integer"
+                }
+            }
+        },
+        "response.ProblemResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "USER_NOT_FOUND"
+                },
+                "correlationId": {
+                    "type": "string"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "errors": {
+                    "description": "Errors lists the fields that broke a rule when Code is VALIDATION_FAILED.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/validation.FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/internal/leaderboard/user/42"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "type": "string",
+                    "example": "user not found"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "urn:goodblast:problem:user-not-found"
+                }
+            }
+        },
+        "response.PublicKey": {
+            "type": "object",
+            "properties": {
+                "kid": {
+                    "type": "string"
+                },
+                "publicKey": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "response.PublicKeysResponse": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/response.PublicKey"
+                    }
                 }
             }
         },
@@ -724,10 +1945,57 @@ const docTemplate = `{
         "response.UserLoginResponse": {
             "type": "object",
             "properties": {
+                "expiresAt": {
+                    "type": "string"
+                },
+                "refreshExpiresAt": {
+                    "type": "string"
+                },
+                "refreshToken": {
+                    "type": "string"
+                },
                 "token": {
                     "type": "string"
                 }
             }
+        },
+        "response.UserStandingResponse": {
+            "type": "object",
+            "properties": {
+                "percentile": {
+                    "description": "Percentile is the share of players on the board ranked below the user.",
+                    "type": "number"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "validation.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "country"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "must be an ISO 3166-1 alpha-2 country code"
+                },
+                "rule": {
+                    "type": "string",
+                    "example": "country"
+                }
+            }
         }
     },
     "securityDefinitions": {
@@ -735,6 +2003,12 @@ const docTemplate = `{
             "type": "apiKey",
             "name": "Authorization",
             "in": "header"
+        },
+        "ServiceSignature": {
+            "description": "HMAC-SHA256 request signature, sent with X-Service-Id and X-Service-Timestamp",
+            "type": "apiKey",
+            "name": "X-Service-Signature",
+            "in": "header"
         }
     }
 }`
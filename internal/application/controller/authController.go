@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"goodblast/internal/application/controller/response"
+	"goodblast/pkg/auth"
+	"net/http"
+)
+
+type IAuthController interface {
+	GetPublicKeys(ctx *gin.Context)
+}
+
+type AuthController struct {
+	keyring *auth.Keyring
+}
+
+func NewAuthController(keyring *auth.Keyring) IAuthController {
+	return &AuthController{
+		keyring: keyring,
+	}
+}
+
+// GetPublicKeys godoc
+// @Summary List token verification keys
+// @Description Returns the Ed25519 public keys, by key ID, that other services can use to verify v4.public access tokens without the signing secret.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} response.PublicKeysResponse
+// @Router /.well-known/paseto-keys [get]
+func (c *AuthController) GetPublicKeys(ctx *gin.Context) {
+	keys := c.keyring.PublicKeys()
+
+	resp := response.PublicKeysResponse{Keys: make([]response.PublicKey, 0, len(keys))}
+	for _, key := range keys {
+		resp.Keys = append(resp.Keys, response.PublicKey{
+			KeyID:     key.KeyID,
+			Version:   key.Version,
+			PublicKey: key.PublicKey,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,11 @@
+package controller
+
+import (
+	domain "goodblast/internal/domain/errors"
+)
+
+// invalidRequest reports a body or query string that could not be bound, like
+// malformed JSON. Rule violations are reported by the validator instead.
+func invalidRequest(err error) error {
+	return domain.ErrInvalidRequest.WithDetail(err.Error())
+}
@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"goodblast/internal/application/service"
+	domain "goodblast/internal/domain/errors"
+	"net/http"
+	"strconv"
+)
+
+type IFeatureFlagController interface {
+	GetUserFlags(ctx *gin.Context)
+}
+
+type FeatureFlagController struct {
+	featureFlagService service.IFeatureFlagService
+}
+
+func NewFeatureFlagController(featureFlagService service.IFeatureFlagService) IFeatureFlagController {
+	return &FeatureFlagController{
+		featureFlagService: featureFlagService,
+	}
+}
+
+// GetUserFlags godoc
+// @Summary     Evaluate Feature Flags for a User
+// @Description Reports every feature flag as evaluated for the user, with the variant assigned and the reason for the result, for debugging rollouts.
+// @Tags        Feature Flags
+// @Produce     json
+// @Param       userId path int true "User ID"
+// @Success     200 {object} response.FeatureFlagsResponse
+// @Failure     400 {object} response.ProblemResponse "Bad Request"
+// @Failure     403 {object} response.ProblemResponse "Forbidden"
+// @Failure     404 {object} response.ProblemResponse "User Not Found"
+// @Failure     500 {object} response.ProblemResponse "Internal Server Error"
+// @Security    BearerAuth
+// @Security    ServiceSignature
+// @Router      /internal/flags/user/{userId} [get]
+func (c *FeatureFlagController) GetUserFlags(ctx *gin.Context) {
+	userID, err := strconv.ParseInt(ctx.Param("userId"), 10, 64)
+	if err != nil {
+		ctx.Error(domain.ErrInvalidRequest.WithDetail("invalid user ID"))
+		return
+	}
+
+	flags, err := c.featureFlagService.EvaluateForUser(ctx.Request.Context(), userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, flags)
+}
@@ -1,71 +1,385 @@
 package controller
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
+	"goodblast/internal/application/controller/request"
 	"goodblast/internal/application/service"
+	"goodblast/internal/domain/entity"
+	domain "goodblast/internal/domain/errors"
+	"goodblast/internal/validation"
+	"goodblast/pkg/server"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 )
 
+const leaderboardStreamHeartbeat = 15 * time.Second
+
 type LeaderboardController struct {
-	leaderboardService service.ILeaderboardService
+	leaderboardService            service.ILeaderboardService
+	leaderboardMaintenanceService service.ILeaderboardMaintenanceService
+	leaderboardEventService       service.ILeaderboardEventService
+	validator                     validation.Validator
+}
+
+func NewLeaderboardController(
+	service service.ILeaderboardService,
+	maintenanceService service.ILeaderboardMaintenanceService,
+	eventService service.ILeaderboardEventService,
+	validator validation.Validator,
+) *LeaderboardController {
+	return &LeaderboardController{
+		leaderboardService:            service,
+		leaderboardMaintenanceService: maintenanceService,
+		leaderboardEventService:       eventService,
+		validator:                     validator,
+	}
 }
 
-func NewLeaderboardController(service service.ILeaderboardService) *LeaderboardController {
-	return &LeaderboardController{leaderboardService: service}
+// GetLeaderboard godoc
+// @Summary     Get Leaderboard Page
+// @Description Retrieves a page of the global, country or tournament leaderboard. Pass the returned next_cursor to fetch the following page.
+// @Tags        Leaderboard
+// @Produce     json
+// @Param       country      path  string false "Country Code (e.g., US, TR, DE)"
+// @Param       tournamentId path  int    false "Tournament ID"
+// @Param       groupId      path  int    false "Tournament group ID"
+// @Param       period       query string false "Time window for global and country boards" Enums(alltime, daily, weekly)
+// @Param       cursor       query string false "Cursor returned by the previous page"
+// @Param       offset       query int    false "Zero-based offset, ignored when cursor is set"
+// @Param       limit        query int    false "Page size (1-1000, default 100)"
+// @Success     200 {object} response.LeaderboardPage
+// @Failure     400 {object} response.ProblemResponse "Bad Request"
+// @Failure     500 {object} response.ProblemResponse "Internal Server Error"
+// @Router      /internal/leaderboard/global [get]
+// @Router      /internal/leaderboard/country/{country} [get]
+// @Router      /internal/leaderboard/tournament/{tournamentId} [get]
+// @Router      /internal/leaderboard/tournament/{tournamentId}/group/{groupId} [get]
+func (c *LeaderboardController) GetLeaderboard(ctx *gin.Context) {
+	var req request.LeaderboardPageRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(invalidRequest(err))
+		return
+	}
+	if err := c.validator.Validate(&req); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	board, err := leaderboardFromPath(ctx, req.LeaderboardPeriodRequest)
+	if err != nil {
+		ctx.Error(invalidRequest(err))
+		return
+	}
+
+	offset, err := req.ResolveOffset()
+	if err != nil {
+		ctx.Error(domain.ErrInvalidCursor)
+		return
+	}
+
+	page, err := c.leaderboardService.GetLeaderboard(ctx.Request.Context(), board, offset, req.ResolveLimit())
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, page)
 }
 
-// GetGlobalLeaderboard godoc
-// @Summary     Get Global Leaderboard
-// @Description Retrieves the top 1000 users from the global leaderboard.
+// GetAroundMe godoc
+// @Summary     Get Leaderboard Around Me
+// @Description Retrieves the players ranked directly above and below the authenticated user.
 // @Tags        Leaderboard
 // @Produce     json
-// @Success     200 {array} response.LeaderboardEntry
-// @Failure     500 {object} map[string]string "Internal Server Error"
-// @Router      /leaderboard/global [get]
-func (c *LeaderboardController) GetGlobalLeaderboard(ctx *gin.Context) {
-	leaderboard, err := c.leaderboardService.GetGlobalLeaderboard(1000)
+// @Param       country      path  string false "Country Code (e.g., US, TR, DE)"
+// @Param       tournamentId path  int    false "Tournament ID"
+// @Param       period       query string false "Time window for global and country boards" Enums(alltime, daily, weekly)
+// @Param       size         query int    false "Players to return on each side (1-50, default 5)"
+// @Success     200 {object} response.LeaderboardPage
+// @Failure     401 {object} response.ProblemResponse "Unauthorized"
+// @Failure     404 {object} response.ProblemResponse "User is not ranked"
+// @Failure     500 {object} response.ProblemResponse "Internal Server Error"
+// @Security    BearerAuth
+// @Router      /internal/leaderboard/global/around-me [get]
+// @Router      /internal/leaderboard/country/{country}/around-me [get]
+// @Router      /internal/leaderboard/tournament/{tournamentId}/around-me [get]
+func (c *LeaderboardController) GetAroundMe(ctx *gin.Context) {
+	userID, ok := authenticatedUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req request.AroundMeRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(invalidRequest(err))
+		return
+	}
+	if err := c.validator.Validate(&req); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	board, err := leaderboardFromPath(ctx, req.LeaderboardPeriodRequest)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(invalidRequest(err))
 		return
 	}
-	ctx.JSON(http.StatusOK, leaderboard)
+
+	page, err := c.leaderboardService.GetAroundUser(ctx.Request.Context(), board, userID, req.ResolveSize())
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, page)
 }
 
-// GetCountryLeaderboard godoc
-// @Summary     Get Country Leaderboard
-// @Description Retrieves the top 1000 users from the leaderboard for a specific country.
+// GetMyStanding godoc
+// @Summary     Get My Leaderboard Standing
+// @Description Retrieves the rank, score and percentile of the authenticated user.
 // @Tags        Leaderboard
 // @Produce     json
-// @Param       country path string true "Country Code (e.g., US, TR, DE)"
-// @Success     200 {array} response.LeaderboardEntry
-// @Failure     500 {object} map[string]string "Internal Server Error"
-// @Router      /leaderboard/country/{country} [get]
-func (c *LeaderboardController) GetCountryLeaderboard(ctx *gin.Context) {
-	country := ctx.Param("country")
-	leaderboard, err := c.leaderboardService.GetCountryLeaderboard(country, 1000)
+// @Param       country      path string false "Country Code (e.g., US, TR, DE)"
+// @Param       tournamentId path int    false "Tournament ID"
+// @Param       period       query string false "Time window for global and country boards" Enums(alltime, daily, weekly)
+// @Success     200 {object} response.UserStandingResponse
+// @Failure     401 {object} response.ProblemResponse "Unauthorized"
+// @Failure     404 {object} response.ProblemResponse "User is not ranked"
+// @Failure     500 {object} response.ProblemResponse "Internal Server Error"
+// @Security    BearerAuth
+// @Router      /internal/leaderboard/global/me [get]
+// @Router      /internal/leaderboard/country/{country}/me [get]
+// @Router      /internal/leaderboard/tournament/{tournamentId}/me [get]
+func (c *LeaderboardController) GetMyStanding(ctx *gin.Context) {
+	userID, ok := authenticatedUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req request.LeaderboardPeriodRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(invalidRequest(err))
+		return
+	}
+	if err := c.validator.Validate(&req); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	board, err := leaderboardFromPath(ctx, req)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(invalidRequest(err))
 		return
 	}
-	ctx.JSON(http.StatusOK, leaderboard)
+
+	standing, err := c.leaderboardService.GetUserStanding(ctx.Request.Context(), board, userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, standing)
 }
 
 // GetUserRank godoc
 // @Summary     Get User Rank
-// @Description Retrieves the global rank of a specific user.
+// @Description Retrieves the all-time global rank, score and percentile of a specific user.
 // @Tags        Leaderboard
 // @Produce     json
 // @Param       userId path int true "User ID"
-// @Success     200 {object} map[string]interface{} "User Rank"
-// @Failure     404 {object} map[string]string "User Not Found"
-// @Router      /leaderboard/user/{userId}/rank [get]
+// @Success     200 {object} response.UserStandingResponse
+// @Failure     400 {object} response.ProblemResponse "Bad Request"
+// @Failure     404 {object} response.ProblemResponse "User Not Found"
+// @Router      /internal/leaderboard/user/{userId} [get]
 func (c *LeaderboardController) GetUserRank(ctx *gin.Context) {
-	userID, _ := strconv.ParseInt(ctx.Param("userId"), 10, 64)
-	rank, err := c.leaderboardService.GetUserRank(userID)
+	userID, err := strconv.ParseInt(ctx.Param("userId"), 10, 64)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		ctx.Error(domain.ErrInvalidRequest.WithDetail("invalid user ID"))
 		return
 	}
-	ctx.JSON(http.StatusOK, gin.H{"user_id": userID, "rank": rank})
+
+	standing, err := c.leaderboardService.GetUserStanding(ctx.Request.Context(), entity.GlobalLeaderboard(), userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, standing)
+}
+
+// StreamLeaderboard godoc
+// @Summary     Stream Leaderboard Rank Changes
+// @Description Opens a Server-Sent Events stream of rank changes on the authenticated user's tournament group or on a country board. Each "rank" event carries a LeaderboardRankChangedEvent; "heartbeat" events keep idle connections open.
+// @Tags        Leaderboard
+// @Produce     text/event-stream
+// @Param       country path string false "Country Code (e.g., US, TR, DE)"
+// @Success     200 {object} events.LeaderboardRankChangedEvent
+// @Failure     401 {object} response.ProblemResponse "Unauthorized"
+// @Failure     404 {object} response.ProblemResponse "No active tournament or user has not entered it"
+// @Security    BearerAuth
+// @Router      /internal/leaderboard/stream/group [get]
+// @Router      /internal/leaderboard/stream/country/{country} [get]
+func (c *LeaderboardController) StreamLeaderboard(ctx *gin.Context) {
+	userID, ok := authenticatedUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var board entity.Leaderboard
+	if country := ctx.Param("country"); country != "" {
+		board = entity.CountryLeaderboard(country)
+	} else {
+		groupBoard, err := c.leaderboardEventService.ResolveGroupBoard(ctx.Request.Context(), userID)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		board = groupBoard
+	}
+
+	rankChanges, unsubscribe := c.leaderboardEventService.Subscribe(board)
+	defer unsubscribe()
+
+	heartbeat := time.NewTicker(leaderboardStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.SSEvent("subscribed", gin.H{"board": board.Type, "country": board.Country, "tournamentId": board.TournamentID, "groupId": board.GroupID})
+	ctx.Writer.Flush()
+
+	shuttingDown := server.ShuttingDown(ctx.Request.Context())
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Request.Context().Done():
+			return false
+		case <-shuttingDown:
+			return false
+		case event, ok := <-rankChanges:
+			if !ok {
+				return false
+			}
+			ctx.SSEvent("rank", event)
+			return true
+		case <-heartbeat.C:
+			ctx.SSEvent("heartbeat", time.Now().UTC().Unix())
+			return true
+		}
+	})
+}
+
+// RebuildLeaderboards godoc
+// @Summary     Rebuild Leaderboards
+// @Description Repopulates the all-time global and country leaderboards and a tournament leaderboard from Postgres and swaps them in atomically.
+// @Tags        Leaderboard
+// @Accept      json
+// @Produce     json
+// @Param       requestBody body request.RebuildLeaderboardRequest false "Tournament board to rebuild (defaults to the active tournament)"
+// @Success     200 {object} response.LeaderboardRebuildResponse
+// @Failure     400 {object} response.ProblemResponse "Bad Request"
+// @Failure     500 {object} response.ProblemResponse "Internal Server Error"
+// @Failure     403 {object} response.ProblemResponse "Admin or service role required"
+// @Security    BearerAuth
+// @Security    ServiceSignature
+// @Router      /internal/leaderboard/rebuild [post]
+func (c *LeaderboardController) RebuildLeaderboards(ctx *gin.Context) {
+	var req request.RebuildLeaderboardRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.Error(invalidRequest(err))
+			return
+		}
+	}
+	if err := c.validator.Validate(&req); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	result, err := c.leaderboardMaintenanceService.Rebuild(ctx.Request.Context(), req.TournamentID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// ReconcileLeaderboards godoc
+// @Summary     Reconcile Leaderboards
+// @Description Samples users from Postgres, reports score drift against Redis and optionally raises scores that fell behind.
+// @Tags        Leaderboard
+// @Accept      json
+// @Produce     json
+// @Param       requestBody body request.ReconcileLeaderboardRequest false "Sample size and whether to fix drift"
+// @Success     200 {object} response.LeaderboardReconcileReport
+// @Failure     400 {object} response.ProblemResponse "Bad Request"
+// @Failure     500 {object} response.ProblemResponse "Internal Server Error"
+// @Failure     403 {object} response.ProblemResponse "Admin or service role required"
+// @Security    BearerAuth
+// @Security    ServiceSignature
+// @Router      /internal/leaderboard/reconcile [post]
+func (c *LeaderboardController) ReconcileLeaderboards(ctx *gin.Context) {
+	var req request.ReconcileLeaderboardRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.Error(invalidRequest(err))
+			return
+		}
+	}
+	if err := c.validator.Validate(&req); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	report, err := c.leaderboardMaintenanceService.Reconcile(ctx.Request.Context(), req.SampleSize, req.Fix)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
+
+func leaderboardFromPath(ctx *gin.Context, periodReq request.LeaderboardPeriodRequest) (entity.Leaderboard, error) {
+	period := entity.LeaderboardPeriod(periodReq.Period)
+	if period == "" {
+		period = entity.LeaderboardPeriodAllTime
+	}
+
+	if tournamentParam := ctx.Param("tournamentId"); tournamentParam != "" {
+		tournamentID, err := strconv.ParseInt(tournamentParam, 10, 64)
+		if err != nil {
+			return entity.Leaderboard{}, errors.New("invalid tournament ID")
+		}
+		if period != entity.LeaderboardPeriodAllTime {
+			return entity.Leaderboard{}, errors.New("tournament leaderboards do not support periods")
+		}
+		if groupParam := ctx.Param("groupId"); groupParam != "" {
+			groupID, err := strconv.ParseInt(groupParam, 10, 64)
+			if err != nil {
+				return entity.Leaderboard{}, errors.New("invalid group ID")
+			}
+			return entity.TournamentGroupLeaderboard(tournamentID, groupID), nil
+		}
+		return entity.TournamentLeaderboard(tournamentID), nil
+	}
+
+	board := entity.GlobalLeaderboard()
+	if country := ctx.Param("country"); country != "" {
+		board = entity.CountryLeaderboard(country)
+	}
+	return board.ForPeriod(period, time.Now().UTC()), nil
+}
+
+func authenticatedUserID(ctx *gin.Context) (int64, bool) {
+	userIDVal, exists := ctx.Get("userID")
+	if !exists {
+		ctx.Error(domain.ErrUnauthorized)
+		return 0, false
+	}
+
+	userID, ok := userIDVal.(int64)
+	if !ok {
+		ctx.Error(domain.ErrUnauthorized.WithDetail("invalid user ID"))
+		return 0, false
+	}
+	return userID, true
 }
@@ -0,0 +1,11 @@
+package request
+
+type RebuildLeaderboardRequest struct {
+	// TournamentID selects the tournament board to rebuild; the active tournament is used when empty.
+	TournamentID int64 `json:"tournamentId" validate:"min=0"`
+}
+
+type ReconcileLeaderboardRequest struct {
+	SampleSize int  `json:"sampleSize" validate:"omitempty,min=1,max=10000"`
+	Fix        bool `json:"fix"`
+}
@@ -0,0 +1,47 @@
+package request
+
+import "goodblast/pkg/pagination"
+
+const (
+	DefaultLeaderboardPageLimit int64 = 100
+	DefaultAroundMeSize         int64 = 5
+)
+
+// LeaderboardPeriodRequest selects the time window of a global or country board.
+type LeaderboardPeriodRequest struct {
+	Period string `form:"period" validate:"omitempty,oneof=alltime daily weekly"`
+}
+
+type LeaderboardPageRequest struct {
+	LeaderboardPeriodRequest
+	Cursor string `form:"cursor"`
+	Offset int64  `form:"offset" validate:"min=0"`
+	Limit  int64  `form:"limit" validate:"omitempty,min=1,max=1000"`
+}
+
+// ResolveOffset prefers the opaque cursor returned by a previous page over the raw offset.
+func (r LeaderboardPageRequest) ResolveOffset() (int64, error) {
+	if r.Cursor == "" {
+		return r.Offset, nil
+	}
+	return pagination.DecodeOffsetCursor(r.Cursor)
+}
+
+func (r LeaderboardPageRequest) ResolveLimit() int64 {
+	if r.Limit == 0 {
+		return DefaultLeaderboardPageLimit
+	}
+	return r.Limit
+}
+
+type AroundMeRequest struct {
+	LeaderboardPeriodRequest
+	Size int64 `form:"size" validate:"omitempty,min=1,max=50"`
+}
+
+func (r AroundMeRequest) ResolveSize() int64 {
+	if r.Size == 0 {
+		return DefaultAroundMeSize
+	}
+	return r.Size
+}
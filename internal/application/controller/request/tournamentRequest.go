@@ -4,9 +4,9 @@ type CreateDailyTournamentRequest struct {
 }
 
 type StartTournamentReq struct {
-	ID int64 `json:"id" binding:"required"`
+	ID int64 `json:"id" validate:"required,min=1"`
 }
 
 type CloseTournamentReq struct {
-	ID int64 `json:"id" binding:"required"`
+	ID int64 `json:"id" validate:"required,min=1"`
 }
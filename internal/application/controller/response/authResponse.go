@@ -0,0 +1,11 @@
+package response
+
+type PublicKeysResponse struct {
+	Keys []PublicKey `json:"keys"`
+}
+
+type PublicKey struct {
+	KeyID     string `json:"kid"`
+	Version   string `json:"version"`
+	PublicKey string `json:"publicKey"`
+}
@@ -1,6 +1,21 @@
 package response
 
-type ErrorResponse struct {
-	Status      int    `json:"status"`
-	Description string `json:"description"`
+import "goodblast/internal/validation"
+
+// ProblemContentType is the media type of ProblemResponse bodies.
+const ProblemContentType = "application/problem+json"
+
+// ProblemResponse is the RFC 7807 problem details body of every error
+// response. Clients should branch on Code, which is stable; Title and Detail
+// are for humans.
+type ProblemResponse struct {
+	Type          string `json:"type" example:"urn:goodblast:problem:user-not-found"`
+	Title         string `json:"title" example:"user not found"`
+	Status        int    `json:"status" example:"404"`
+	Detail        string `json:"detail,omitempty"`
+	Instance      string `json:"instance,omitempty" example:"/internal/leaderboard/user/42"`
+	Code          string `json:"code" example:"USER_NOT_FOUND"`
+	CorrelationID string `json:"correlationId,omitempty"`
+	// Errors lists the fields that broke a rule when Code is VALIDATION_FAILED.
+	Errors []validation.FieldError `json:"errors,omitempty"`
 }
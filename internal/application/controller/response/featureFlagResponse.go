@@ -0,0 +1,15 @@
+package response
+
+type FeatureFlagsResponse struct {
+	UserID  int64                   `json:"userId"`
+	Country string                  `json:"country"`
+	Level   int                     `json:"level"`
+	Flags   []FeatureFlagEvaluation `json:"flags"`
+}
+
+type FeatureFlagEvaluation struct {
+	Flag    string `json:"flag"`
+	Enabled bool   `json:"enabled"`
+	Variant string `json:"variant,omitempty"`
+	Reason  string `json:"reason"`
+}
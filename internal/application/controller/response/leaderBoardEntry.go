@@ -1,7 +1,27 @@
 package response
 
 type LeaderboardEntry struct {
+	UserID   int64  `json:"user_id"`
+	Username string `json:"username,omitempty"`
+	Country  string `json:"country,omitempty"`
+	Level    int    `json:"level,omitempty"`
+	Score    int64  `json:"score"`
+	Rank     int64  `json:"rank"`
+}
+
+type LeaderboardPage struct {
+	Entries    []LeaderboardEntry `json:"entries"`
+	Total      int64              `json:"total"`
+	Offset     int64              `json:"offset"`
+	Limit      int64              `json:"limit"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+type UserStandingResponse struct {
 	UserID int64 `json:"user_id"`
-	Score  int64 `json:"score"`
 	Rank   int64 `json:"rank"`
+	Score  int64 `json:"score"`
+	// Percentile is the share of players on the board ranked below the user.
+	Percentile float64 `json:"percentile"`
+	Total      int64   `json:"total"`
 }
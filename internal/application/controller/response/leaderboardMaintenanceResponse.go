@@ -0,0 +1,24 @@
+package response
+
+type LeaderboardRebuildResponse struct {
+	Users           int64 `json:"users"`
+	Countries       int   `json:"countries"`
+	TournamentID    int64 `json:"tournament_id,omitempty"`
+	TournamentUsers int64 `json:"tournament_users"`
+	DurationMs      int64 `json:"duration_ms"`
+}
+
+type LeaderboardReconcileReport struct {
+	Sampled int                `json:"sampled"`
+	Drifted int                `json:"drifted"`
+	Fixed   int                `json:"fixed"`
+	Drifts  []LeaderboardDrift `json:"drifts,omitempty"`
+}
+
+type LeaderboardDrift struct {
+	UserID   int64  `json:"user_id"`
+	Key      string `json:"key"`
+	Expected int64  `json:"expected"`
+	// Actual is nil when the user is missing from the sorted set.
+	Actual *int64 `json:"actual"`
+}
@@ -5,6 +5,8 @@ import (
 	"goodblast/internal/application/controller/request"
 	"goodblast/internal/application/controller/response"
 	"goodblast/internal/application/service"
+	domain "goodblast/internal/domain/errors"
+	"goodblast/internal/validation"
 	"net/http"
 )
 
@@ -18,12 +20,14 @@ type ITournamentController interface {
 }
 
 type TournamentController struct {
-	service service.ITournamentService
+	service   service.ITournamentService
+	validator validation.Validator
 }
 
-func NewTournamentController(service service.ITournamentService) ITournamentController {
+func NewTournamentController(service service.ITournamentService, validator validation.Validator) ITournamentController {
 	return &TournamentController{
-		service: service,
+		service:   service,
+		validator: validator,
 	}
 }
 
@@ -34,7 +38,11 @@ func NewTournamentController(service service.ITournamentService) ITournamentCont
 // @Accept      json
 // @Produce     json
 // @Success     200 {object} response.CreateDailyTournamentResponse
-// @Failure     500 {object} map[string]string
+// @Failure     500 {object} response.ProblemResponse
+// @Failure     401 {object} response.ProblemResponse "Unauthorized"
+// @Failure     403 {object} response.ProblemResponse "Admin or service role required"
+// @Security    BearerAuth
+// @Security    ServiceSignature
 // @Router      /internal/tournament/create-daily [post]
 func (ctrl *TournamentController) CreateDailyTournament(ctx *gin.Context) {
 	tournament, err := ctrl.service.CreateDailyTournament(ctx.Request.Context())
@@ -61,14 +69,22 @@ func (ctrl *TournamentController) CreateDailyTournament(ctx *gin.Context) {
 // @Produce     json
 // @Param       requestBody body  request.StartTournamentReq true "Tournament ID to start"
 // @Success     200   {object}  response.StartTournamentResponse
-// @Failure     400   {object}  map[string]string "Bad Request"
-// @Failure     409   {object}  map[string]string "Tournament Already Ended/Closed"
-// @Failure     500   {object}  map[string]string "Internal Error"
+// @Failure     400   {object}  response.ProblemResponse "Bad Request"
+// @Failure     409   {object}  response.ProblemResponse "Tournament Already Ended/Closed"
+// @Failure     500   {object}  response.ProblemResponse "Internal Error"
+// @Failure     401 {object} response.ProblemResponse "Unauthorized"
+// @Failure     403 {object} response.ProblemResponse "Admin or service role required"
+// @Security    BearerAuth
+// @Security    ServiceSignature
 // @Router      /internal/tournament/start [post]
 func (ctrl *TournamentController) StartTournament(ctx *gin.Context) {
 	var req request.StartTournamentReq
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		ctx.Error(invalidRequest(err))
+		return
+	}
+	if err := ctrl.validator.Validate(&req); err != nil {
+		ctx.Error(err)
 		return
 	}
 
@@ -91,14 +107,22 @@ func (ctrl *TournamentController) StartTournament(ctx *gin.Context) {
 // @Produce     json
 // @Param       requestBody body  request.CloseTournamentReq true "Tournament ID to close"
 // @Success     200   {object} response.CloseTournamentResponse
-// @Failure     400   {object} map[string]string
-// @Failure     409   {object} map[string]string "Tournament Already Ended/Closed"
-// @Failure     500   {object} map[string]string
+// @Failure     400   {object} response.ProblemResponse
+// @Failure     409   {object} response.ProblemResponse "Tournament Already Ended/Closed"
+// @Failure     500   {object} response.ProblemResponse
+// @Failure     401 {object} response.ProblemResponse "Unauthorized"
+// @Failure     403 {object} response.ProblemResponse "Admin or service role required"
+// @Security    BearerAuth
+// @Security    ServiceSignature
 // @Router      /internal/tournament/close [post]
 func (ctrl *TournamentController) CloseTournament(ctx *gin.Context) {
 	var req request.CloseTournamentReq
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		ctx.Error(invalidRequest(err))
+		return
+	}
+	if err := ctrl.validator.Validate(&req); err != nil {
+		ctx.Error(err)
 		return
 	}
 
@@ -108,7 +132,8 @@ func (ctrl *TournamentController) CloseTournament(ctx *gin.Context) {
 		return
 	}
 
-	if err := ctrl.service.StoreRewards(ctx, req.ID); err != nil {
+	if err := ctrl.service.StoreRewards(ctx.Request.Context(), req.ID); err != nil {
+		ctx.Error(err)
 		return
 	}
 
@@ -121,8 +146,8 @@ func (ctrl *TournamentController) CloseTournament(ctx *gin.Context) {
 // @Tags        Tournament
 // @Produce     json
 // @Success     200 {object} response.GetActiveTournamentResponse
-// @Failure     404 {object} map[string]string "No active tournament found"
-// @Failure     500 {object} map[string]string
+// @Failure     404 {object} response.ProblemResponse "No active tournament found"
+// @Failure     500 {object} response.ProblemResponse
 // @Router      /internal/tournament/active [get]
 func (ctrl *TournamentController) GetActiveTournament(ctx *gin.Context) {
 	tournament, err := ctrl.service.GetActiveTournament(ctx.Request.Context())
@@ -131,7 +156,7 @@ func (ctrl *TournamentController) GetActiveTournament(ctx *gin.Context) {
 		return
 	}
 	if tournament == nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "no active tournament"})
+		ctx.Error(domain.ErrNoActiveTournament)
 		return
 	}
 
@@ -150,22 +175,16 @@ func (ctrl *TournamentController) GetActiveTournament(ctx *gin.Context) {
 // @Tags        Tournament
 // @Produce     json
 // @Success     200 {object} map[string]string "Joined the tournament successfully"
-// @Failure     401 {object} map[string]string "Unauthorized or invalid user ID"
-// @Failure     403 {object} map[string]string "Forbidden if user level or coins are insufficient"
-// @Failure     404 {object} map[string]string "No active tournament found"
-// @Failure     409 {object} map[string]string "Tournament already ended (if needed)"
-// @Failure     500 {object} map[string]string "Internal server error"
+// @Failure     401 {object} response.ProblemResponse "Unauthorized or invalid user ID"
+// @Failure     403 {object} response.ProblemResponse "Forbidden if user level or coins are insufficient"
+// @Failure     404 {object} response.ProblemResponse "No active tournament found"
+// @Failure     409 {object} response.ProblemResponse "Tournament already ended (if needed)"
+// @Failure     500 {object} response.ProblemResponse "Internal server error"
 // @Security    BearerAuth
 // @Router      /internal/tournament/enter [post]
 func (ctrl *TournamentController) EnterTournament(ctx *gin.Context) {
-	userIDVal, exists := ctx.Get("userID")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
-	}
-	userID, ok := userIDVal.(int64)
+	userID, ok := authenticatedUserID(ctx)
 	if !ok {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
 		return
 	}
 
@@ -185,21 +204,14 @@ func (ctrl *TournamentController) EnterTournament(ctx *gin.Context) {
 // @Accept      json
 // @Produce     json
 // @Success     200 {object} map[string]string  "All rewards claimed successfully"
-// @Failure     401 {object} map[string]string  "Unauthorized or invalid user ID"
-// @Failure     404 {object} map[string]string  "No unclaimed rewards found"
-// @Failure     500 {object} map[string]string  "Server error or database failure"
+// @Failure     401 {object} response.ProblemResponse  "Unauthorized or invalid user ID"
+// @Failure     404 {object} response.ProblemResponse  "No unclaimed rewards found"
+// @Failure     500 {object} response.ProblemResponse  "Server error or database failure"
 // @Security    BearerAuth
 // @Router      /internal/tournament/reward/claim [post]
 func (ctrl *TournamentController) ClaimReward(ctx *gin.Context) {
-	userIDVal, exists := ctx.Get("userID")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
-	}
-
-	userID, ok := userIDVal.(int64)
+	userID, ok := authenticatedUserID(ctx)
 	if !ok {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
 		return
 	}
 
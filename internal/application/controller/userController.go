@@ -1,13 +1,13 @@
 package controller
 
 import (
-	"fmt"
 	"github.com/gin-gonic/gin"
 	"goodblast/internal/application/controller/request"
-	"goodblast/internal/application/controller/response"
 	"goodblast/internal/application/service"
+	domain "goodblast/internal/domain/errors"
 	"goodblast/internal/validation"
 	"goodblast/pkg/auth"
+	"goodblast/pkg/constants"
 	"goodblast/pkg/log"
 	"net/http"
 )
@@ -15,18 +15,22 @@ import (
 type IUserController interface {
 	CreateUser(ctx *gin.Context)
 	Login(ctx *gin.Context)
+	RefreshToken(ctx *gin.Context)
+	Logout(ctx *gin.Context)
 	UpdateProgress(ctx *gin.Context)
 }
 
 type UserController struct {
-	userService service.IUserService
-	validator   validation.Validator
+	userService  service.IUserService
+	tokenService service.ITokenService
+	validator    validation.Validator
 }
 
-func NewUserController(userService service.IUserService, validator validation.Validator) IUserController {
+func NewUserController(userService service.IUserService, tokenService service.ITokenService, validator validation.Validator) IUserController {
 	return &UserController{
-		userService: userService,
-		validator:   validator,
+		userService:  userService,
+		tokenService: tokenService,
+		validator:    validator,
 	}
 }
 
@@ -37,26 +41,23 @@ func NewUserController(userService service.IUserService, validator validation.Va
 // @Produce json
 // @Param requestBody body request.CreateUserRequest true "User object that needs to be created"
 // @Success 200 {object} int64
-// @Failure 400 {object} response.ErrorResponse
-// @Failure 500 {object} response.ErrorResponse
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
 // @Router /internal/user [post]
 func (userController *UserController) CreateUser(ctx *gin.Context) {
 	var req request.CreateUserRequest
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, response.ErrorResponse{Status: http.StatusBadRequest, Description: err.Error()})
+		ctx.Error(invalidRequest(err))
 		return
 	}
 
 	if err := userController.validator.Validate(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, response.ErrorResponse{
-			Status:      http.StatusBadRequest,
-			Description: err.Error(),
-		})
+		ctx.Error(err)
 		return
 	}
 
-	log.GetLogger().Info(fmt.Sprintf("UserController.CreateUser - Start Request: %#v", req))
+	log.FromContext(ctx.Request.Context()).WithField("username", req.Username).Info("UserController.CreateUser - Start Request")
 
 	userId, err := userController.userService.CreateUser(ctx.Request.Context(), req)
 
@@ -65,7 +66,7 @@ func (userController *UserController) CreateUser(ctx *gin.Context) {
 		return
 	}
 
-	log.GetLogger().Info(fmt.Sprintf("UserController.CreateUser - End Request userId: %#v", userId))
+	log.FromContext(ctx.Request.Context()).Infof("UserController.CreateUser - End Request userId: %d", userId)
 
 	ctx.JSON(http.StatusOK, userId)
 }
@@ -77,42 +78,110 @@ func (userController *UserController) CreateUser(ctx *gin.Context) {
 // @Produce json
 // @Param requestBody body request.UserLoginRequest true "User object that needs to be created"
 // @Success 200 {object} response.UserLoginResponse
-// @Failure 400 {object} response.ErrorResponse
-// @Failure 401 {object} response.ErrorResponse
-// @Failure 500 {object} response.ErrorResponse
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
 // @Router /internal/user/login [post]
 func (userController *UserController) Login(ctx *gin.Context) {
 	var req request.UserLoginRequest
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, response.ErrorResponse{Status: http.StatusBadRequest, Description: err.Error()})
+		ctx.Error(invalidRequest(err))
 		return
 	}
 
 	if err := userController.validator.Validate(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, response.ErrorResponse{
-			Status:      http.StatusBadRequest,
-			Description: err.Error(),
-		})
+		ctx.Error(err)
 		return
 	}
 
-	log.GetLogger().Info(fmt.Sprintf("UserController.Login - Start Request: %#v", req))
+	log.FromContext(ctx.Request.Context()).WithField("username", req.Username).Info("UserController.Login - Start Request")
+
+	user, err := userController.userService.Login(ctx.Request.Context(), req, ctx.ClientIP())
 
-	user, err := userController.userService.Login(ctx.Request.Context(), req)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
 
+	tokens, err := userController.tokenService.IssueTokens(ctx.Request.Context(), user)
 	if err != nil {
 		ctx.Error(err)
 		return
 	}
 
-	token, err := auth.GetAuth().GenerateToken(user)
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+// RefreshToken godoc
+// @Summary Refresh access token
+// @Description Exchanges a refresh token for a new access token and a new refresh token. Each refresh token can be used once; reusing one revokes the whole session.
+// @Tags User Controller
+// @Accept json
+// @Produce json
+// @Param requestBody body request.RefreshTokenRequest true "Refresh token returned by login or a previous refresh"
+// @Success 200 {object} response.UserLoginResponse
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
+// @Router /internal/user/token/refresh [post]
+func (userController *UserController) RefreshToken(ctx *gin.Context) {
+	var req request.RefreshTokenRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(invalidRequest(err))
+		return
+	}
+
+	if err := userController.validator.Validate(&req); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	tokens, err := userController.tokenService.Refresh(ctx.Request.Context(), req.RefreshToken)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{Status: http.StatusInternalServerError, Description: err.Error()})
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, response.UserLoginResponse{Token: token})
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+// Logout godoc
+// @Summary Logout user
+// @Description Revokes the access token used for the request and the given refresh token. Set allSessions to revoke every token of the user.
+// @Tags User Controller
+// @Accept json
+// @Produce json
+// @Param requestBody body request.LogoutRequest false "Refresh token to revoke"
+// @Success 200
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
+// @Security BearerAuth
+// @Router /internal/user/logout [post]
+func (userController *UserController) Logout(ctx *gin.Context) {
+	var req request.LogoutRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.Error(invalidRequest(err))
+			return
+		}
+	}
+
+	payload, ok := ctx.Get(constants.TokenPayloadKey)
+	if !ok {
+		ctx.Error(domain.ErrUnauthorized)
+		return
+	}
+
+	err := userController.tokenService.Logout(ctx.Request.Context(), payload.(auth.TokenPayload), req.RefreshToken, req.AllSessions)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
 // UpdateProgress godoc
@@ -121,27 +190,20 @@ func (userController *UserController) Login(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Success 200
-// @Failure 400 {object} response.ErrorResponse
-// @Failure 401 {object} response.ErrorResponse
-// @Failure 500 {object} response.ErrorResponse
+// @Failure 400 {object} response.ProblemResponse
+// @Failure 401 {object} response.ProblemResponse
+// @Failure 500 {object} response.ProblemResponse
 // @Security BearerAuth
 // @Router /internal/user/progress [post]
 func (userController *UserController) UpdateProgress(ctx *gin.Context) {
-	userID, exists := ctx.Get("userID")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
-	}
-
-	userIDInt64, ok := userID.(int64)
+	userID, ok := authenticatedUserID(ctx)
 	if !ok {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
 		return
 	}
 
-	err := userController.userService.UpdateProgress(ctx.Request.Context(), userIDInt64)
+	err := userController.userService.UpdateProgress(ctx.Request.Context(), userID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
@@ -0,0 +1,524 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"goodblast/internal/domain/entity"
+	"goodblast/pkg/log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Leaderboard key schema. Every board lives under the "lb:" namespace and
+// carries its type as the second segment, so a country code can never collide
+// with the global or a tournament board:
+//
+//	lb:global                             all-time board, scored by user level
+//	lb:global:daily:<yyyy-mm-dd>          levels gained that UTC day
+//	lb:global:weekly:<yyyy-Www>           levels gained that ISO week
+//	<daily or weekly board>:start         hash of each user's level before the period
+//	<board>:generation                    bumped on every write, versions cached pages
+//	lb:country:<ISO country>[:<period>]   same boards per country, country upper-cased
+//	lb:tournament:<tournament id>         tournament scores
+//	lb:tournament:<id>:group:<group id>   tournament scores within one group
+//	lb:events:<board key without lb:>     pub/sub channel announcing rank changes
+//	lb:tmp:<rebuild id>:<key>             staging keys used while rebuilding
+//	lb:schema                             key schema version, see MigrateLegacyKeys
+//	lb:schema:lock                        held by the instance upgrading the schema
+//
+// Sorted set members are decimal user IDs. Daily and weekly boards expire one
+// period after they close.
+//
+// The schema version is bumped whenever stored boards have to be migrated or
+// rebuilt:
+//
+//	1  the original "leaderboard:<name>" keys, which never recorded a version;
+//	   global and country boards held tournament scores
+//	2  boards moved under the "lb:" namespace
+//	3  all-time boards scored by user level, daily and weekly boards added
+//	4  tournament group boards added
+const (
+	leaderboardNamespace     = "lb:"
+	LeaderboardEventPattern  = leaderboardNamespace + "events:*"
+	leaderboardSchemaKey     = leaderboardNamespace + "schema"
+	leaderboardSchemaLockKey = leaderboardSchemaKey + ":lock"
+	leaderboardSchemaVersion = 4
+	legacyLeaderboardPrefix  = "leaderboard:"
+	periodStartSuffix        = ":start"
+	generationSuffix         = ":generation"
+	rebuildBatchSize         = 500
+)
+
+type ILeaderboardStore interface {
+	Key(board entity.Leaderboard) string
+	EventChannel(board entity.Leaderboard) string
+	SetScore(ctx context.Context, userID int64, score int64, boards ...entity.Leaderboard) error
+	RecordPeriodLevel(ctx context.Context, userID int64, level int64, boards ...entity.Leaderboard) error
+	RaiseScore(ctx context.Context, userID int64, score int64, boards ...entity.Leaderboard) error
+	CountryBoards(ctx context.Context) ([]string, error)
+	Generation(ctx context.Context, board entity.Leaderboard) (int64, error)
+	Count(ctx context.Context, board entity.Leaderboard) (int64, error)
+	Range(ctx context.Context, board entity.Leaderboard, start, stop int64) ([]entity.LeaderboardMember, error)
+	Rank(ctx context.Context, board entity.Leaderboard, userID int64) (*entity.LeaderboardMember, error)
+	BatchScores(ctx context.Context, lookups []LeaderboardLookup) ([]*int64, error)
+	NewRebuild() ILeaderboardRebuild
+	MigrateLegacyKeys(ctx context.Context) (bool, error)
+	LockSchema(ctx context.Context, owner string, ttl time.Duration) (bool, error)
+	UnlockSchema(ctx context.Context, owner string) error
+}
+
+type LeaderboardLookup struct {
+	Board  entity.Leaderboard
+	UserID int64
+}
+
+type LeaderboardStore struct {
+	redisClient *redis.Client
+}
+
+func NewLeaderboardStore(redisClient *redis.Client) ILeaderboardStore {
+	return &LeaderboardStore{redisClient: redisClient}
+}
+
+func (s *LeaderboardStore) Key(board entity.Leaderboard) string {
+	var key string
+	switch board.Type {
+	case entity.LeaderboardTypeCountry:
+		key = leaderboardNamespace + "country:" + normalizeCountry(board.Country)
+	case entity.LeaderboardTypeTournament:
+		key = leaderboardNamespace + "tournament:" + strconv.FormatInt(board.TournamentID, 10)
+		if board.GroupID != 0 {
+			key += ":group:" + strconv.FormatInt(board.GroupID, 10)
+		}
+		return key
+	default:
+		key = leaderboardNamespace + "global"
+	}
+
+	if board.IsPeriodic() {
+		key += ":" + string(board.Period) + ":" + board.Bucket()
+	}
+	return key
+}
+
+func (s *LeaderboardStore) EventChannel(board entity.Leaderboard) string {
+	return leaderboardNamespace + "events:" + strings.TrimPrefix(s.Key(board), leaderboardNamespace)
+}
+
+func (s *LeaderboardStore) SetScore(ctx context.Context, userID int64, score int64, boards ...entity.Leaderboard) error {
+	member := redis.Z{Score: float64(score), Member: strconv.FormatInt(userID, 10)}
+	pipe := s.redisClient.Pipeline()
+	for _, board := range boards {
+		key := s.Key(board)
+		pipe.ZAdd(ctx, key, member)
+		s.written(ctx, pipe, key, board)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to set leaderboard score for user %d: %w", userID, err)
+	}
+	return nil
+}
+
+// periodLevelScript sets a periodic board score to the levels gained in the
+// period. The level before the user's first level-up in the period is kept in
+// a companion hash, so a redelivered progress message sets the same score
+// again instead of counting twice. KEYS are triples of board, hash and
+// generation, ARGV the member, the level and the expiry of each board.
+var periodLevelScript = redis.NewScript(`
+local member = ARGV[1]
+local level = tonumber(ARGV[2])
+for i = 1, #KEYS, 3 do
+	local expireAt = ARGV[3 + (i - 1) / 3]
+	redis.call("HSETNX", KEYS[i + 1], member, level - 1)
+	local start = tonumber(redis.call("HGET", KEYS[i + 1], member))
+	redis.call("ZADD", KEYS[i], "GT", level - start, member)
+	redis.call("INCR", KEYS[i + 2])
+	for j = i, i + 2 do
+		redis.call("EXPIREAT", KEYS[j], expireAt)
+	end
+end
+return 0
+`)
+
+// RecordPeriodLevel scores the user on the daily or weekly boards by the
+// levels gained since the period started.
+func (s *LeaderboardStore) RecordPeriodLevel(ctx context.Context, userID int64, level int64, boards ...entity.Leaderboard) error {
+	keys := make([]string, 0, 3*len(boards))
+	args := []interface{}{strconv.FormatInt(userID, 10), level}
+	for _, board := range boards {
+		if !board.IsPeriodic() {
+			return fmt.Errorf("leaderboard %s has no period", s.Key(board))
+		}
+		key := s.Key(board)
+		keys = append(keys, key, key+periodStartSuffix, key+generationSuffix)
+		args = append(args, board.PeriodEnd().Add(board.Retention()).Unix())
+	}
+	if err := periodLevelScript.Run(ctx, s.redisClient, keys, args...).Err(); err != nil {
+		return fmt.Errorf("failed to record period level for user %d: %w", userID, err)
+	}
+	return nil
+}
+
+// RaiseScore sets the score unless the board already holds a higher one, so a
+// score read from Postgres earlier cannot undo progress recorded since.
+func (s *LeaderboardStore) RaiseScore(ctx context.Context, userID int64, score int64, boards ...entity.Leaderboard) error {
+	member := redis.Z{Score: float64(score), Member: strconv.FormatInt(userID, 10)}
+	pipe := s.redisClient.Pipeline()
+	for _, board := range boards {
+		key := s.Key(board)
+		pipe.ZAddGT(ctx, key, member)
+		s.written(ctx, pipe, key, board)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to raise leaderboard score for user %d: %w", userID, err)
+	}
+	return nil
+}
+
+// CountryBoards returns the countries that have an all-time board.
+func (s *LeaderboardStore) CountryBoards(ctx context.Context) ([]string, error) {
+	prefix := leaderboardNamespace + "country:"
+
+	var countries []string
+	iter := s.redisClient.Scan(ctx, 0, prefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		country := strings.TrimPrefix(iter.Val(), prefix)
+		if strings.Contains(country, ":") {
+			continue
+		}
+		countries = append(countries, country)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return countries, nil
+}
+
+// written bumps the generation of a board that was written to and sets the
+// expiry of periodic boards.
+func (s *LeaderboardStore) written(ctx context.Context, pipe redis.Pipeliner, key string, board entity.Leaderboard) {
+	pipe.Incr(ctx, key+generationSuffix)
+	if board.IsPeriodic() {
+		expireAt := board.PeriodEnd().Add(board.Retention())
+		pipe.ExpireAt(ctx, key, expireAt)
+		pipe.ExpireAt(ctx, key+generationSuffix, expireAt)
+	}
+}
+
+// Generation returns a counter that changes whenever the board is written to,
+// so cached reads of the board can be versioned by it.
+func (s *LeaderboardStore) Generation(ctx context.Context, board entity.Leaderboard) (int64, error) {
+	generation, err := s.redisClient.Get(ctx, s.Key(board)+generationSuffix).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return generation, err
+}
+
+func (s *LeaderboardStore) Count(ctx context.Context, board entity.Leaderboard) (int64, error) {
+	return s.redisClient.ZCard(ctx, s.Key(board)).Result()
+}
+
+func (s *LeaderboardStore) Range(ctx context.Context, board entity.Leaderboard, start, stop int64) ([]entity.LeaderboardMember, error) {
+	users, err := s.redisClient.ZRevRangeWithScores(ctx, s.Key(board), start, stop).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]entity.LeaderboardMember, 0, len(users))
+	for i, user := range users {
+		userID, _ := strconv.ParseInt(user.Member.(string), 10, 64)
+		members = append(members, entity.LeaderboardMember{
+			UserID: userID,
+			Score:  int64(user.Score),
+			Rank:   start + int64(i) + 1,
+		})
+	}
+	return members, nil
+}
+
+// Rank returns the user's 1-based rank and score, or nil when the user is not on the board.
+func (s *LeaderboardStore) Rank(ctx context.Context, board entity.Leaderboard, userID int64) (*entity.LeaderboardMember, error) {
+	key := s.Key(board)
+	member := strconv.FormatInt(userID, 10)
+
+	pipe := s.redisClient.Pipeline()
+	rankCmd := pipe.ZRevRank(ctx, key, member)
+	scoreCmd := pipe.ZScore(ctx, key, member)
+	if _, err := pipe.Exec(ctx); err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &entity.LeaderboardMember{
+		UserID: userID,
+		Score:  int64(scoreCmd.Val()),
+		Rank:   rankCmd.Val() + 1,
+	}, nil
+}
+
+// BatchScores looks up several scores in one round trip. A nil result means
+// the user is missing from that board.
+func (s *LeaderboardStore) BatchScores(ctx context.Context, lookups []LeaderboardLookup) ([]*int64, error) {
+	pipe := s.redisClient.Pipeline()
+	cmds := make([]*redis.FloatCmd, len(lookups))
+	for i, lookup := range lookups {
+		cmds[i] = pipe.ZScore(ctx, s.Key(lookup.Board), strconv.FormatInt(lookup.UserID, 10))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	scores := make([]*int64, len(lookups))
+	for i, cmd := range cmds {
+		value, err := cmd.Result()
+		if err != nil {
+			continue
+		}
+		score := int64(value)
+		scores[i] = &score
+	}
+	return scores, nil
+}
+
+func (s *LeaderboardStore) NewRebuild() ILeaderboardRebuild {
+	return &leaderboardRebuild{
+		store:    s,
+		prefix:   leaderboardNamespace + "tmp:" + uuid.NewString() + ":",
+		tempKeys: make(map[string]string),
+		pending:  make(map[string][]redis.Z),
+	}
+}
+
+// MigrateLegacyKeys moves boards written under the old "leaderboard:<...>"
+// keys into the namespaced schema and records the schema version. It reports
+// whether the stored schema was older than the current one, in which case the
+// all-time boards still hold tournament scores and should be rebuilt.
+func (s *LeaderboardStore) MigrateLegacyKeys(ctx context.Context) (bool, error) {
+	version, err := s.redisClient.Get(ctx, leaderboardSchemaKey).Int()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return false, err
+	}
+	if version >= leaderboardSchemaVersion {
+		return false, nil
+	}
+
+	iter := s.redisClient.Scan(ctx, 0, legacyLeaderboardPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		legacyKey := iter.Val()
+		keyType, err := s.redisClient.Type(ctx, legacyKey).Result()
+		if err != nil {
+			return false, err
+		}
+		if keyType != "zset" {
+			log.GetLogger().Warnf("Skipping legacy key %s of type %s, only sorted sets are leaderboards", legacyKey, keyType)
+			continue
+		}
+
+		target, ok := s.legacyTarget(legacyKey)
+		if !ok {
+			if err := s.redisClient.Del(ctx, legacyKey).Err(); err != nil {
+				return false, err
+			}
+			continue
+		}
+
+		if err := s.mergeInto(ctx, legacyKey, target); err != nil {
+			return false, fmt.Errorf("failed to migrate %s to %s: %w", legacyKey, target, err)
+		}
+		log.GetLogger().Infof("Migrated leaderboard key %s to %s", legacyKey, target)
+	}
+	if err := iter.Err(); err != nil {
+		return false, err
+	}
+
+	if err := s.redisClient.Set(ctx, leaderboardSchemaKey, leaderboardSchemaVersion, 0).Err(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// LockSchema takes the schema upgrade lock for owner unless another instance
+// holds it. The lock expires after ttl in case its owner dies.
+func (s *LeaderboardStore) LockSchema(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	return s.redisClient.SetNX(ctx, leaderboardSchemaLockKey, owner, ttl).Result()
+}
+
+// unlockScript deletes the lock only while owner still holds it, so a lock
+// that expired and was taken by another instance is left alone.
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+func (s *LeaderboardStore) UnlockSchema(ctx context.Context, owner string) error {
+	return unlockScript.Run(ctx, s.redisClient, []string{leaderboardSchemaLockKey}, owner).Err()
+}
+
+// legacyTarget maps an old sorted set to its namespaced equivalent. Leftover
+// rebuild staging keys have no equivalent and are dropped.
+func (s *LeaderboardStore) legacyTarget(legacyKey string) (string, bool) {
+	name := strings.TrimPrefix(legacyKey, legacyLeaderboardPrefix)
+	switch {
+	case name == "global":
+		return s.Key(entity.GlobalLeaderboard()), true
+	case strings.HasPrefix(name, "rebuild:"):
+		return "", false
+	case strings.HasPrefix(name, "tournament:"):
+		tournamentID, err := strconv.ParseInt(strings.TrimPrefix(name, "tournament:"), 10, 64)
+		if err != nil {
+			return "", false
+		}
+		return s.Key(entity.TournamentLeaderboard(tournamentID)), true
+	default:
+		return s.Key(entity.CountryLeaderboard(name)), true
+	}
+}
+
+func (s *LeaderboardStore) mergeInto(ctx context.Context, source, target string) error {
+	renamed, err := s.redisClient.RenameNX(ctx, source, target).Result()
+	if err != nil || renamed {
+		return err
+	}
+
+	_, err = s.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZUnionStore(ctx, target, &redis.ZStore{Keys: []string{target, source}, Aggregate: "MAX"})
+		pipe.Del(ctx, source)
+		return nil
+	})
+	return err
+}
+
+func normalizeCountry(country string) string {
+	return strings.ToUpper(strings.TrimSpace(country))
+}
+
+// ILeaderboardRebuild stages scores in temporary keys and swaps them over the
+// live boards in a single MULTI/EXEC.
+type ILeaderboardRebuild interface {
+	Touch(board entity.Leaderboard)
+	Add(ctx context.Context, board entity.Leaderboard, userID int64, score int64) error
+	Commit(ctx context.Context) error
+	Discard(ctx context.Context)
+}
+
+type leaderboardRebuild struct {
+	store       *LeaderboardStore
+	prefix      string
+	tempKeys    map[string]string
+	pending     map[string][]redis.Z
+	pendingSize int
+}
+
+// Touch registers a board so it is replaced even if no scores are added to it.
+func (r *leaderboardRebuild) Touch(board entity.Leaderboard) {
+	r.tempKey(board)
+}
+
+func (r *leaderboardRebuild) Add(ctx context.Context, board entity.Leaderboard, userID int64, score int64) error {
+	tempKey := r.tempKey(board)
+	r.pending[tempKey] = append(r.pending[tempKey], redis.Z{
+		Score:  float64(score),
+		Member: strconv.FormatInt(userID, 10),
+	})
+	r.pendingSize++
+
+	if r.pendingSize < rebuildBatchSize {
+		return nil
+	}
+	return r.flush(ctx)
+}
+
+func (r *leaderboardRebuild) Commit(ctx context.Context) error {
+	if err := r.flush(ctx); err != nil {
+		return err
+	}
+
+	exists, err := r.existingTempKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.store.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, tempKey := range r.tempKeys {
+			if exists[tempKey] {
+				pipe.Rename(ctx, tempKey, key)
+			} else {
+				pipe.Del(ctx, key)
+			}
+			pipe.Incr(ctx, key+generationSuffix)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to swap rebuilt leaderboards: %w", err)
+	}
+	return nil
+}
+
+// Discard removes staging keys left behind when a rebuild fails midway.
+func (r *leaderboardRebuild) Discard(ctx context.Context) {
+	if len(r.tempKeys) == 0 {
+		return
+	}
+	tempKeys := make([]string, 0, len(r.tempKeys))
+	for _, tempKey := range r.tempKeys {
+		tempKeys = append(tempKeys, tempKey)
+	}
+	if err := r.store.redisClient.Del(ctx, tempKeys...).Err(); err != nil {
+		log.GetLogger().WithError(err).Warn("Failed to clean up leaderboard rebuild keys")
+	}
+}
+
+func (r *leaderboardRebuild) tempKey(board entity.Leaderboard) string {
+	key := r.store.Key(board)
+	tempKey, ok := r.tempKeys[key]
+	if !ok {
+		tempKey = r.prefix + key
+		r.tempKeys[key] = tempKey
+	}
+	return tempKey
+}
+
+func (r *leaderboardRebuild) flush(ctx context.Context) error {
+	if r.pendingSize == 0 {
+		return nil
+	}
+
+	pipe := r.store.redisClient.Pipeline()
+	for tempKey, members := range r.pending {
+		pipe.ZAdd(ctx, tempKey, members...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to write rebuild batch: %w", err)
+	}
+
+	r.pending = make(map[string][]redis.Z)
+	r.pendingSize = 0
+	return nil
+}
+
+func (r *leaderboardRebuild) existingTempKeys(ctx context.Context) (map[string]bool, error) {
+	pipe := r.store.redisClient.Pipeline()
+	cmds := make(map[string]*redis.IntCmd, len(r.tempKeys))
+	for _, tempKey := range r.tempKeys {
+		cmds[tempKey] = pipe.Exists(ctx, tempKey)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to inspect rebuilt leaderboards: %w", err)
+	}
+
+	exists := make(map[string]bool, len(cmds))
+	for tempKey, cmd := range cmds {
+		exists[tempKey] = cmd.Val() > 0
+	}
+	return exists, nil
+}
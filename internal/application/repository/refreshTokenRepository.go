@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+	"goodblast/internal/domain/entity"
+	"time"
+)
+
+type IRefreshTokenRepository interface {
+	Create(ctx context.Context, token *entity.RefreshToken) error
+	CreateTx(ctx context.Context, tx bun.Tx, token *entity.RefreshToken) error
+	FindByHashForUpdateTx(ctx context.Context, tx bun.Tx, tokenHash string) (*entity.RefreshToken, error)
+	RotateTx(ctx context.Context, tx bun.Tx, tokenID, replacedByID int64) error
+	RevokeByHash(ctx context.Context, userID int64, tokenHash string) error
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeAllForUser(ctx context.Context, userID int64) error
+}
+
+type RefreshTokenRepository struct {
+	db *bun.DB
+}
+
+func NewRefreshTokenRepository(db *bun.DB) IRefreshTokenRepository {
+	return &RefreshTokenRepository{db: db}
+}
+
+func (r *RefreshTokenRepository) Create(ctx context.Context, token *entity.RefreshToken) error {
+	_, err := r.db.NewInsert().
+		Model(token).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to create refresh token")
+	}
+	return nil
+}
+
+func (r *RefreshTokenRepository) CreateTx(ctx context.Context, tx bun.Tx, token *entity.RefreshToken) error {
+	_, err := tx.NewInsert().
+		Model(token).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to create refresh token")
+	}
+	return nil
+}
+
+func (r *RefreshTokenRepository) FindByHashForUpdateTx(ctx context.Context, tx bun.Tx, tokenHash string) (*entity.RefreshToken, error) {
+	var token entity.RefreshToken
+	err := tx.NewSelect().
+		Model(&token).
+		Where("token_hash = ?", tokenHash).
+		For("UPDATE").
+		Scan(ctx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to find refresh token")
+	}
+	return &token, nil
+}
+
+func (r *RefreshTokenRepository) RotateTx(ctx context.Context, tx bun.Tx, tokenID, replacedByID int64) error {
+	_, err := tx.NewUpdate().
+		Model((*entity.RefreshToken)(nil)).
+		Set("revoked_at = ?", time.Now().UTC()).
+		Set("replaced_by_id = ?", replacedByID).
+		Where("id = ?", tokenID).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to rotate refresh token")
+	}
+	return nil
+}
+
+func (r *RefreshTokenRepository) RevokeByHash(ctx context.Context, userID int64, tokenHash string) error {
+	_, err := r.db.NewUpdate().
+		Model((*entity.RefreshToken)(nil)).
+		Set("revoked_at = ?", time.Now().UTC()).
+		Where("user_id = ?", userID).
+		Where("token_hash = ?", tokenHash).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to revoke refresh token")
+	}
+	return nil
+}
+
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	_, err := r.db.NewUpdate().
+		Model((*entity.RefreshToken)(nil)).
+		Set("revoked_at = ?", time.Now().UTC()).
+		Where("family_id = ?", familyID).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to revoke refresh token family")
+	}
+	return nil
+}
+
+func (r *RefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID int64) error {
+	_, err := r.db.NewUpdate().
+		Model((*entity.RefreshToken)(nil)).
+		Set("revoked_at = ?", time.Now().UTC()).
+		Where("user_id = ?", userID).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to revoke refresh tokens")
+	}
+	return nil
+}
@@ -22,9 +22,12 @@ func NewTournamentRewardRepository(db *bun.DB) ITournamentRewardRepository {
 	return &TournamentRewardRepository{db: db}
 }
 
+// CreateRewards skips users already rewarded for the tournament, so storing
+// the rewards of a tournament twice pays nobody twice.
 func (r *TournamentRewardRepository) CreateRewards(ctx context.Context, rewards []entity.TournamentReward) error {
 	_, err := r.db.NewInsert().
 		Model(&rewards).
+		On("CONFLICT (tournament_id, user_id) DO NOTHING").
 		Exec(ctx)
 	if err != nil {
 		return errors.Wrap(err, "failed to create tournament rewards")
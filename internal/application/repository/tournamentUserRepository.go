@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"github.com/pkg/errors"
 	"github.com/uptrace/bun"
 	"goodblast/internal/domain/entity"
@@ -14,6 +15,8 @@ type ITournamentUserRepository interface {
 	UpdateScore(ctx context.Context, tu *entity.TournamentUser) error
 	GetTournamentUsersByTournament(ctx context.Context, tournamentID int64) ([]entity.TournamentUser, error)
 	GetTournamentUsersByGroup(ctx context.Context, tournamentID int64, groupID int) ([]entity.TournamentUser, error)
+	StreamTournamentScores(ctx context.Context, tournamentID int64, fn func(score entity.LeaderboardScore) error) error
+	SampleTournamentScores(ctx context.Context, tournamentID int64, sampleSize int) ([]entity.LeaderboardScore, error)
 }
 
 type TournamentUserRepository struct {
@@ -84,3 +87,46 @@ func (r *TournamentUserRepository) GetTournamentUsersByGroup(ctx context.Context
 	}
 	return list, nil
 }
+
+func (r *TournamentUserRepository) StreamTournamentScores(ctx context.Context, tournamentID int64, fn func(score entity.LeaderboardScore) error) error {
+	rows, err := r.db.NewSelect().
+		TableExpr("tournament_users AS tu").
+		ColumnExpr("tu.user_id, u.country, tu.tournament_id, tu.group_id, tu.score").
+		Join("JOIN users AS u ON u.id = tu.user_id").
+		Where("tu.tournament_id = ?", tournamentID).
+		Rows(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to stream tournament scores")
+	}
+	return r.scanScores(ctx, rows, fn)
+}
+
+func (r *TournamentUserRepository) SampleTournamentScores(ctx context.Context, tournamentID int64, sampleSize int) ([]entity.LeaderboardScore, error) {
+	var list []entity.LeaderboardScore
+	err := r.db.NewSelect().
+		TableExpr("tournament_users AS tu").
+		ColumnExpr("tu.user_id, u.country, tu.tournament_id, tu.group_id, tu.score").
+		Join("JOIN users AS u ON u.id = tu.user_id").
+		Where("tu.tournament_id = ?", tournamentID).
+		OrderExpr("random()").
+		Limit(sampleSize).
+		Scan(ctx, &list)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sample tournament scores")
+	}
+	return list, nil
+}
+
+func (r *TournamentUserRepository) scanScores(ctx context.Context, rows *sql.Rows, fn func(score entity.LeaderboardScore) error) error {
+	defer rows.Close()
+	for rows.Next() {
+		var score entity.LeaderboardScore
+		if err := r.db.ScanRow(ctx, rows, &score); err != nil {
+			return errors.Wrap(err, "failed to scan tournament score")
+		}
+		if err := fn(score); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -13,9 +13,13 @@ type IUserRepository interface {
 	CreateUser(ctx context.Context, user *entity.User) (int64, error)
 	GetUserByUsername(ctx context.Context, username string) (*entity.User, error)
 	GetUserByID(ctx context.Context, userId int64) (*entity.User, error)
+	GetUsersByIDs(ctx context.Context, userIDs []int64) ([]entity.User, error)
 	UpdateProgress(ctx context.Context, user *entity.User) error
 	FindUserForUpdateTx(ctx context.Context, tx bun.Tx, userID int64) (*entity.User, error)
 	UpdateUserTx(ctx context.Context, tx bun.Tx, u *entity.User) error
+	AddCoins(ctx context.Context, userID int64, amount int64) (*entity.User, error)
+	StreamLevels(ctx context.Context, fn func(score entity.LeaderboardScore) error) error
+	SampleLevels(ctx context.Context, sampleSize int) ([]entity.LeaderboardScore, error)
 }
 
 type UserRepository struct {
@@ -60,6 +64,21 @@ func (usrRepo *UserRepository) GetUserByID(ctx context.Context, userId int64) (*
 	return &user, nil
 }
 
+func (usrRepo *UserRepository) GetUsersByIDs(ctx context.Context, userIDs []int64) ([]entity.User, error) {
+	var users []entity.User
+	if len(userIDs) == 0 {
+		return users, nil
+	}
+	err := usrRepo.db.NewSelect().
+		Model(&users).
+		Where("id IN (?)", bun.In(userIDs)).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch users by IDs")
+	}
+	return users, nil
+}
+
 func (usrRepo *UserRepository) UpdateProgress(ctx context.Context, user *entity.User) error {
 	_, err := usrRepo.db.NewUpdate().
 		Model(user).
@@ -101,3 +120,61 @@ func (usrRepo *UserRepository) UpdateUserTx(ctx context.Context, tx bun.Tx, u *e
 	}
 	return nil
 }
+
+// AddCoins adjusts the balance in a single statement and returns the updated
+// user, or nil when the user does not exist or the balance would go below
+// zero.
+func (usrRepo *UserRepository) AddCoins(ctx context.Context, userID int64, amount int64) (*entity.User, error) {
+	var u entity.User
+	err := usrRepo.db.NewUpdate().
+		Model(&u).
+		Set("coins = coins + ?", amount).
+		Where("id = ?", userID).
+		Where("coins + ? >= 0", amount).
+		Returning("*").
+		Scan(ctx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to add coins")
+	}
+	return &u, nil
+}
+
+// StreamLevels walks every user's level, which is the score of the all-time leaderboards.
+func (usrRepo *UserRepository) StreamLevels(ctx context.Context, fn func(score entity.LeaderboardScore) error) error {
+	rows, err := usrRepo.db.NewSelect().
+		Model((*entity.User)(nil)).
+		ColumnExpr("id AS user_id, country, level AS score").
+		Rows(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to stream user levels")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var score entity.LeaderboardScore
+		if err := usrRepo.db.ScanRow(ctx, rows, &score); err != nil {
+			return errors.Wrap(err, "failed to scan user level")
+		}
+		if err := fn(score); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (usrRepo *UserRepository) SampleLevels(ctx context.Context, sampleSize int) ([]entity.LeaderboardScore, error) {
+	var list []entity.LeaderboardScore
+	err := usrRepo.db.NewSelect().
+		Model((*entity.User)(nil)).
+		ColumnExpr("id AS user_id, country, level AS score").
+		OrderExpr("random()").
+		Limit(sampleSize).
+		Scan(ctx, &list)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sample user levels")
+	}
+	return list, nil
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	appconfig "goodblast/config"
+	"goodblast/internal/application/controller/response"
+	"goodblast/internal/application/repository"
+	"goodblast/internal/domain/entity"
+	domain "goodblast/internal/domain/errors"
+	"goodblast/pkg/log"
+)
+
+type IFeatureFlagService interface {
+	IsEnabled(ctx context.Context, flag string, user *entity.User) bool
+	Variant(ctx context.Context, flag string, user *entity.User) string
+	EvaluateForUser(ctx context.Context, userID int64) (*response.FeatureFlagsResponse, error)
+}
+
+// FeatureFlagService evaluates the flags of the dynamic config for users of
+// the domain.
+type FeatureFlagService struct {
+	uRepo                repository.IUserRepository
+	dynamicConfigService appconfig.IDynamicConfigService
+}
+
+func NewFeatureFlagService(uRepo repository.IUserRepository, dynamicConfigService appconfig.IDynamicConfigService) IFeatureFlagService {
+	return &FeatureFlagService{
+		uRepo:                uRepo,
+		dynamicConfigService: dynamicConfigService,
+	}
+}
+
+func (s *FeatureFlagService) IsEnabled(ctx context.Context, flag string, user *entity.User) bool {
+	return s.dynamicConfigService.IsEnabled(ctx, flag, flagUser(user))
+}
+
+func (s *FeatureFlagService) Variant(ctx context.Context, flag string, user *entity.User) string {
+	return s.dynamicConfigService.Variant(ctx, flag, flagUser(user))
+}
+
+func (s *FeatureFlagService) EvaluateForUser(ctx context.Context, userID int64) (*response.FeatureFlagsResponse, error) {
+	user, err := s.uRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		log.FromContext(ctx).WithError(err).Errorf("Failed to load user %d to evaluate flags", userID)
+		return nil, domain.ErrInternalServerError
+	}
+	if user == nil {
+		return nil, domain.ErrUserNotFound
+	}
+
+	evaluations := s.dynamicConfigService.EvaluateFlags(ctx, flagUser(user))
+	flags := make([]response.FeatureFlagEvaluation, 0, len(evaluations))
+	for _, evaluation := range evaluations {
+		flags = append(flags, response.FeatureFlagEvaluation{
+			Flag:    evaluation.Flag,
+			Enabled: evaluation.Enabled,
+			Variant: evaluation.Variant,
+			Reason:  evaluation.Reason,
+		})
+	}
+
+	return &response.FeatureFlagsResponse{
+		UserID:  user.ID,
+		Country: user.Country,
+		Level:   user.Level,
+		Flags:   flags,
+	}, nil
+}
+
+func flagUser(user *entity.User) appconfig.FlagUser {
+	return appconfig.FlagUser{ID: user.ID, Country: user.Country, Level: user.Level}
+}
@@ -3,120 +3,225 @@ package service
 import (
 	"context"
 	"fmt"
-	"github.com/redis/go-redis/v9"
 	"goodblast/internal/application/controller/response"
+	"goodblast/internal/application/repository"
+	"goodblast/internal/domain/entity"
+	domain "goodblast/internal/domain/errors"
 	"goodblast/pkg/cache"
-	"strconv"
+	"goodblast/pkg/log"
+	"goodblast/pkg/pagination"
+	"math"
 	"time"
 )
 
 type ILeaderboardService interface {
-	UpdateUserScore(userID int64, score int64, country string) error
-	GetGlobalLeaderboard(limit int64) ([]response.LeaderboardEntry, error)
-	GetCountryLeaderboard(country string, limit int64) ([]response.LeaderboardEntry, error)
-	GetUserRank(userID int64) (int64, error)
+	RecordProgress(ctx context.Context, userID int64, country string, level int) error
+	GetLeaderboard(ctx context.Context, board entity.Leaderboard, offset, limit int64) (*response.LeaderboardPage, error)
+	GetAroundUser(ctx context.Context, board entity.Leaderboard, userID int64, size int64) (*response.LeaderboardPage, error)
+	GetUserStanding(ctx context.Context, board entity.Leaderboard, userID int64) (*response.UserStandingResponse, error)
 }
 
+const leaderboardPageCacheTTL = 30 * time.Second
+
 type LeaderboardService struct {
-	redisClient *redis.Client
+	leaderboardStore        repository.ILeaderboardStore
+	userProfileService      IUserProfileService
+	leaderboardEventService ILeaderboardEventService
+	pageCache               cache.Cache
 }
 
-func NewLeaderboardService(redisClient *redis.Client) ILeaderboardService {
-	cache.InitCache()
+func NewLeaderboardService(
+	leaderboardStore repository.ILeaderboardStore,
+	userProfileService IUserProfileService,
+	leaderboardEventService ILeaderboardEventService,
+	pageCache cache.Cache,
+) ILeaderboardService {
 	return &LeaderboardService{
-		redisClient: redisClient,
+		leaderboardStore:        leaderboardStore,
+		userProfileService:      userProfileService,
+		leaderboardEventService: leaderboardEventService,
+		pageCache:               pageCache,
 	}
 }
 
-func (s *LeaderboardService) UpdateUserScore(userID int64, score int64, country string) error {
-	ctx := context.Background()
-	_, err := s.redisClient.ZAdd(ctx, "leaderboard:global", redis.Z{
-		Score:  float64(score),
-		Member: userID,
-	}).Result()
-	if err != nil {
+// RecordProgress applies a level-up to the non-tournament boards: the all-time
+// boards track the user's level while the daily and weekly boards hold the
+// levels gained in the current period. Both are derived from the level and
+// only ever raised, so a redelivered or reordered message changes nothing.
+// Messages published before the level was added carry none and are skipped.
+func (s *LeaderboardService) RecordProgress(ctx context.Context, userID int64, country string, level int) error {
+	if level <= 0 {
+		log.FromContext(ctx).Warnf("Skipping leaderboard progress without a level for user %d", userID)
+		return nil
+	}
+
+	now := time.Now().UTC()
+	boards := []entity.Leaderboard{entity.GlobalLeaderboard()}
+	if country != "" {
+		boards = append(boards, entity.CountryLeaderboard(country))
+	}
+
+	if err := s.leaderboardStore.RaiseScore(ctx, userID, int64(level), boards...); err != nil {
 		return err
 	}
 
-	_, err = s.redisClient.ZAdd(ctx, "leaderboard:"+country, redis.Z{
-		Score:  float64(score),
-		Member: userID,
-	}).Result()
-	if err != nil {
+	var periodic []entity.Leaderboard
+	for _, board := range boards {
+		periodic = append(periodic,
+			board.ForPeriod(entity.LeaderboardPeriodDaily, now),
+			board.ForPeriod(entity.LeaderboardPeriodWeekly, now),
+		)
+	}
+	if err := s.leaderboardStore.RecordPeriodLevel(ctx, userID, int64(level), periodic...); err != nil {
 		return err
 	}
 
-	cacheKeyGlobal := "leaderboard:global"
-	cacheKeyCountry := fmt.Sprintf("leaderboard:country:%s", country)
-	cache.SetCache(cacheKeyGlobal, nil, 1*time.Millisecond)
-	cache.SetCache(cacheKeyCountry, nil, 1*time.Millisecond)
+	if country != "" {
+		if err := s.leaderboardEventService.PublishRankChange(ctx, entity.CountryLeaderboard(country), userID); err != nil {
+			log.FromContext(ctx).WithError(err).Errorf("Failed to publish country rank change for user %d", userID)
+		}
+	}
 
 	return nil
 }
 
-func (s *LeaderboardService) GetGlobalLeaderboard(limit int64) ([]response.LeaderboardEntry, error) {
-	cacheKey := "leaderboard:global"
-	var leaderboard []response.LeaderboardEntry
+func (s *LeaderboardService) GetLeaderboard(ctx context.Context, board entity.Leaderboard, offset, limit int64) (*response.LeaderboardPage, error) {
+	// Pages are cached under the board's generation, which every write bumps,
+	// so no instance serves a page older than the last write.
+	generation, err := s.leaderboardStore.Generation(ctx, board)
+	if err != nil {
+		return nil, err
+	}
 
-	cached, err := cache.GetCache(cacheKey, &leaderboard)
-	if cached {
-		return leaderboard, nil
+	var page response.LeaderboardPage
+	err = s.pageCache.GetOrLoad(ctx, s.pageCacheKey(board, generation, offset, limit), &page, leaderboardPageCacheTTL,
+		func(ctx context.Context) (interface{}, error) {
+			return s.loadPage(ctx, board, offset, limit)
+		})
+	if err != nil {
+		return nil, err
 	}
+	return &page, nil
+}
 
-	ctx := context.Background()
-	users, err := s.redisClient.ZRevRangeWithScores(ctx, "leaderboard:global", 0, limit-1).Result()
+func (s *LeaderboardService) loadPage(ctx context.Context, board entity.Leaderboard, offset, limit int64) (*response.LeaderboardPage, error) {
+	total, err := s.leaderboardStore.Count(ctx, board)
 	if err != nil {
 		return nil, err
 	}
 
-	for rank, user := range users {
-		userID, _ := strconv.ParseInt(user.Member.(string), 10, 64)
-		leaderboard = append(leaderboard, response.LeaderboardEntry{
-			UserID: userID,
-			Score:  int64(user.Score),
-			Rank:   int64(rank + 1),
-		})
+	entries, err := s.readRange(ctx, board, offset, offset+limit-1)
+	if err != nil {
+		return nil, err
 	}
 
-	cache.SetCache(cacheKey, leaderboard, 30*time.Second)
+	page := &response.LeaderboardPage{
+		Entries: entries,
+		Total:   total,
+		Offset:  offset,
+		Limit:   limit,
+	}
+	if offset+limit < total {
+		page.NextCursor = pagination.EncodeOffsetCursor(offset + limit)
+	}
 
-	return leaderboard, nil
+	return page, nil
 }
 
-func (s *LeaderboardService) GetCountryLeaderboard(country string, limit int64) ([]response.LeaderboardEntry, error) {
-	cacheKey := fmt.Sprintf("leaderboard:country:%s", country)
-	var leaderboard []response.LeaderboardEntry
+func (s *LeaderboardService) GetAroundUser(ctx context.Context, board entity.Leaderboard, userID int64, size int64) (*response.LeaderboardPage, error) {
+	member, err := s.leaderboardStore.Rank(ctx, board, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, domain.ErrUserNotRanked
+	}
 
-	cached, err := cache.GetCache(cacheKey, &leaderboard)
-	if cached {
-		return leaderboard, nil
+	total, err := s.leaderboardStore.Count(ctx, board)
+	if err != nil {
+		return nil, err
 	}
 
-	ctx := context.Background()
-	users, err := s.redisClient.ZRevRangeWithScores(ctx, "leaderboard:"+country, 0, limit-1).Result()
+	start := max(member.Rank-1-size, 0)
+	stop := member.Rank - 1 + size
+
+	entries, err := s.readRange(ctx, board, start, stop)
 	if err != nil {
 		return nil, err
 	}
 
-	for rank, user := range users {
-		userID, _ := strconv.ParseInt(user.Member.(string), 10, 64)
-		leaderboard = append(leaderboard, response.LeaderboardEntry{
-			UserID: userID,
-			Score:  int64(user.Score),
-			Rank:   int64(rank + 1),
-		})
+	return &response.LeaderboardPage{
+		Entries: entries,
+		Total:   total,
+		Offset:  start,
+		Limit:   stop - start + 1,
+	}, nil
+}
+
+func (s *LeaderboardService) GetUserStanding(ctx context.Context, board entity.Leaderboard, userID int64) (*response.UserStandingResponse, error) {
+	member, err := s.leaderboardStore.Rank(ctx, board, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, domain.ErrUserNotRanked
 	}
 
-	cache.SetCache(cacheKey, leaderboard, 30*time.Second)
+	total, err := s.leaderboardStore.Count(ctx, board)
+	if err != nil {
+		return nil, err
+	}
 
-	return leaderboard, nil
+	return &response.UserStandingResponse{
+		UserID:     userID,
+		Rank:       member.Rank,
+		Score:      member.Score,
+		Percentile: percentile(member.Rank, total),
+		Total:      total,
+	}, nil
 }
 
-func (s *LeaderboardService) GetUserRank(userID int64) (int64, error) {
-	ctx := context.Background()
-	rank, err := s.redisClient.ZRevRank(ctx, "leaderboard:global", fmt.Sprintf("%d", userID)).Result()
+func (s *LeaderboardService) readRange(ctx context.Context, board entity.Leaderboard, start, stop int64) ([]response.LeaderboardEntry, error) {
+	members, err := s.leaderboardStore.Range(ctx, board, start, stop)
 	if err != nil {
-		return -1, err
+		return nil, err
+	}
+
+	entries := make([]response.LeaderboardEntry, 0, len(members))
+	userIDs := make([]int64, 0, len(members))
+	for _, member := range members {
+		userIDs = append(userIDs, member.UserID)
+		entries = append(entries, response.LeaderboardEntry{
+			UserID: member.UserID,
+			Score:  member.Score,
+			Rank:   member.Rank,
+		})
+	}
+
+	profiles, err := s.userProfileService.GetProfiles(ctx, userIDs)
+	if err != nil {
+		log.FromContext(ctx).WithError(err).Warnf("Failed to load user profiles for leaderboard %s", s.leaderboardStore.Key(board))
+		return entries, nil
+	}
+
+	for i := range entries {
+		if profile, ok := profiles[entries[i].UserID]; ok {
+			entries[i].Username = profile.Username
+			entries[i].Country = profile.Country
+			entries[i].Level = profile.Level
+		}
+	}
+	return entries, nil
+}
+
+func (s *LeaderboardService) pageCacheKey(board entity.Leaderboard, generation, offset, limit int64) string {
+	return fmt.Sprintf("%s:%d:page:%d:%d", s.leaderboardStore.Key(board), generation, offset, limit)
+}
+
+func percentile(rank, total int64) float64 {
+	if total == 0 {
+		return 0
 	}
-	return rank + 1, nil
+	value := float64(total-rank) / float64(total) * 100
+	return math.Round(value*100) / 100
 }
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/redis/go-redis/v9"
+	"goodblast/internal/application/repository"
+	"goodblast/internal/domain/entity"
+	domain "goodblast/internal/domain/errors"
+	"goodblast/internal/domain/events"
+	"goodblast/pkg/log"
+	"sync"
+	"time"
+)
+
+const (
+	leaderboardSubscriberBuffer = 64
+	leaderboardResubscribeDelay = time.Second
+)
+
+type ILeaderboardEventService interface {
+	PublishRankChange(ctx context.Context, board entity.Leaderboard, userID int64) error
+	Subscribe(board entity.Leaderboard) (<-chan events.LeaderboardRankChangedEvent, func())
+	ResolveGroupBoard(ctx context.Context, userID int64) (entity.Leaderboard, error)
+	Run(ctx context.Context)
+}
+
+// LeaderboardEventService fans rank changes out to streaming clients. Writers
+// publish to a Redis channel per board and every replica runs a single pattern
+// subscription that forwards messages to its local subscribers, so a client
+// sees changes applied by any replica.
+type LeaderboardEventService struct {
+	redisClient      *redis.Client
+	leaderboardStore repository.ILeaderboardStore
+	tRepo            repository.ITournamentRepository
+	tuRepo           repository.ITournamentUserRepository
+
+	mu          sync.RWMutex
+	subscribers map[string]map[chan events.LeaderboardRankChangedEvent]struct{}
+}
+
+func NewLeaderboardEventService(
+	redisClient *redis.Client,
+	leaderboardStore repository.ILeaderboardStore,
+	tRepo repository.ITournamentRepository,
+	tuRepo repository.ITournamentUserRepository,
+) ILeaderboardEventService {
+	return &LeaderboardEventService{
+		redisClient:      redisClient,
+		leaderboardStore: leaderboardStore,
+		tRepo:            tRepo,
+		tuRepo:           tuRepo,
+		subscribers:      make(map[string]map[chan events.LeaderboardRankChangedEvent]struct{}),
+	}
+}
+
+// PublishRankChange announces the user's current position on the board. Users
+// that are not on the board are skipped.
+func (s *LeaderboardEventService) PublishRankChange(ctx context.Context, board entity.Leaderboard, userID int64) error {
+	member, err := s.leaderboardStore.Rank(ctx, board, userID)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(events.LeaderboardRankChangedEvent{
+		Board:  s.leaderboardStore.Key(board),
+		UserID: member.UserID,
+		Score:  member.Score,
+		Rank:   member.Rank,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.redisClient.Publish(ctx, s.leaderboardStore.EventChannel(board), data).Err()
+}
+
+// Subscribe registers a local listener for the board. The returned function
+// must be called to release it. Slow listeners drop events instead of
+// blocking the fan-out.
+func (s *LeaderboardEventService) Subscribe(board entity.Leaderboard) (<-chan events.LeaderboardRankChangedEvent, func()) {
+	channel := s.leaderboardStore.EventChannel(board)
+	ch := make(chan events.LeaderboardRankChangedEvent, leaderboardSubscriberBuffer)
+
+	s.mu.Lock()
+	if s.subscribers[channel] == nil {
+		s.subscribers[channel] = make(map[chan events.LeaderboardRankChangedEvent]struct{})
+	}
+	s.subscribers[channel][ch] = struct{}{}
+	s.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			s.mu.Lock()
+			delete(s.subscribers[channel], ch)
+			if len(s.subscribers[channel]) == 0 {
+				delete(s.subscribers, channel)
+			}
+			s.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// ResolveGroupBoard returns the board of the group the user plays in for the
+// active tournament.
+func (s *LeaderboardEventService) ResolveGroupBoard(ctx context.Context, userID int64) (entity.Leaderboard, error) {
+	tournament, err := s.tRepo.GetActiveTournament(ctx)
+	if err != nil || tournament == nil {
+		return entity.Leaderboard{}, domain.ErrNoActiveTournament
+	}
+
+	tournamentUser, err := s.tuRepo.GetTournamentUser(ctx, tournament.ID, userID)
+	if err != nil || tournamentUser == nil {
+		return entity.Leaderboard{}, domain.ErrNotInTournament
+	}
+
+	return entity.TournamentGroupLeaderboard(tournament.ID, tournamentUser.GroupID), nil
+}
+
+// Run relays Redis messages to local subscribers until ctx is cancelled,
+// resubscribing if the connection drops.
+func (s *LeaderboardEventService) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		s.relay(ctx)
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(leaderboardResubscribeDelay):
+		}
+	}
+}
+
+func (s *LeaderboardEventService) relay(ctx context.Context) {
+	pubsub := s.redisClient.PSubscribe(ctx, repository.LeaderboardEventPattern)
+	defer pubsub.Close()
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		log.GetLogger().WithError(err).Error("Failed to subscribe to leaderboard events")
+		return
+	}
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				log.GetLogger().Warn("Leaderboard event subscription closed, resubscribing")
+				return
+			}
+
+			var event events.LeaderboardRankChangedEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				log.GetLogger().WithError(err).Error("Failed to unmarshal leaderboard event")
+				continue
+			}
+			s.dispatch(msg.Channel, event)
+		}
+	}
+}
+
+func (s *LeaderboardEventService) dispatch(channel string, event events.LeaderboardRankChangedEvent) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for ch := range s.subscribers[channel] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
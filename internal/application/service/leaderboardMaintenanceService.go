@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	appconfig "goodblast/config"
+	"goodblast/internal/application/controller/response"
+	"goodblast/internal/application/repository"
+	"goodblast/internal/domain/entity"
+	"goodblast/pkg/log"
+	"time"
+)
+
+const defaultReconcileSampleSize = 500
+
+// schemaUpgradeTimeout bounds a schema upgrade, including the rebuild it may
+// trigger, and is how long the upgrade lock is held at most.
+const schemaUpgradeTimeout = 10 * time.Minute
+
+type ILeaderboardMaintenanceService interface {
+	Rebuild(ctx context.Context, tournamentID int64) (*response.LeaderboardRebuildResponse, error)
+	Reconcile(ctx context.Context, sampleSize int, fix bool) (*response.LeaderboardReconcileReport, error)
+	ReconcileWithConfig(ctx context.Context) (*response.LeaderboardReconcileReport, error)
+	UpgradeSchema(ctx context.Context) error
+}
+
+type LeaderboardMaintenanceService struct {
+	leaderboardStore     repository.ILeaderboardStore
+	uRepo                repository.IUserRepository
+	tRepo                repository.ITournamentRepository
+	tuRepo               repository.ITournamentUserRepository
+	dynamicConfigService appconfig.IDynamicConfigService
+}
+
+func NewLeaderboardMaintenanceService(
+	leaderboardStore repository.ILeaderboardStore,
+	uRepo repository.IUserRepository,
+	tRepo repository.ITournamentRepository,
+	tuRepo repository.ITournamentUserRepository,
+	dynamicConfigService appconfig.IDynamicConfigService,
+) ILeaderboardMaintenanceService {
+	return &LeaderboardMaintenanceService{
+		leaderboardStore:     leaderboardStore,
+		uRepo:                uRepo,
+		tRepo:                tRepo,
+		tuRepo:               tuRepo,
+		dynamicConfigService: dynamicConfigService,
+	}
+}
+
+// Rebuild repopulates the all-time global and country boards from user levels
+// and the tournament and group boards from tournament scores. Scores are
+// staged in temporary keys and swapped in atomically so readers never observe
+// a half-built board. Country boards left without users are removed. Daily and
+// weekly boards have no Postgres history and are left untouched.
+func (s *LeaderboardMaintenanceService) Rebuild(ctx context.Context, tournamentID int64) (*response.LeaderboardRebuildResponse, error) {
+	startedAt := time.Now()
+	rebuild := s.leaderboardStore.NewRebuild()
+	defer rebuild.Discard(ctx)
+
+	result := &response.LeaderboardRebuildResponse{}
+	countries := make(map[string]struct{})
+
+	rebuild.Touch(entity.GlobalLeaderboard())
+	existingCountries, err := s.leaderboardStore.CountryBoards(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, country := range existingCountries {
+		rebuild.Touch(entity.CountryLeaderboard(country))
+	}
+
+	err = s.uRepo.StreamLevels(ctx, func(score entity.LeaderboardScore) error {
+		result.Users++
+		if err := rebuild.Add(ctx, entity.GlobalLeaderboard(), score.UserID, int64(score.Score)); err != nil {
+			return err
+		}
+		if score.Country == "" {
+			return nil
+		}
+		countries[score.Country] = struct{}{}
+		return rebuild.Add(ctx, entity.CountryLeaderboard(score.Country), score.UserID, int64(score.Score))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if tournamentID == 0 {
+		if tournament, err := s.tRepo.GetActiveTournament(ctx); err == nil && tournament != nil {
+			tournamentID = tournament.ID
+		}
+	}
+
+	if tournamentID != 0 {
+		result.TournamentID = tournamentID
+		board := entity.TournamentLeaderboard(tournamentID)
+		rebuild.Touch(board)
+		err = s.tuRepo.StreamTournamentScores(ctx, tournamentID, func(score entity.LeaderboardScore) error {
+			result.TournamentUsers++
+			if err := rebuild.Add(ctx, board, score.UserID, int64(score.Score)); err != nil {
+				return err
+			}
+			if score.GroupID == 0 {
+				return nil
+			}
+			return rebuild.Add(ctx, entity.TournamentGroupLeaderboard(tournamentID, score.GroupID), score.UserID, int64(score.Score))
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := rebuild.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	result.Countries = len(countries)
+	result.DurationMs = time.Since(startedAt).Milliseconds()
+
+	log.FromContext(ctx).Infof("Leaderboards rebuilt: users=%d, countries=%d, tournament=%d, tournamentUsers=%d, took=%dms",
+		result.Users, result.Countries, result.TournamentID, result.TournamentUsers, result.DurationMs)
+
+	return result, nil
+}
+
+// Reconcile compares a random sample of user levels and active tournament
+// scores with the sorted sets and, when fix is set, raises the entries that
+// fell behind. Entries ahead of Postgres may have progressed since they were
+// sampled, so they are only reported; Rebuild replaces them.
+func (s *LeaderboardMaintenanceService) Reconcile(ctx context.Context, sampleSize int, fix bool) (*response.LeaderboardReconcileReport, error) {
+	if sampleSize <= 0 {
+		sampleSize = defaultReconcileSampleSize
+	}
+
+	var lookups []repository.LeaderboardLookup
+	var expected []int64
+	addLookup := func(board entity.Leaderboard, score entity.LeaderboardScore) {
+		lookups = append(lookups, repository.LeaderboardLookup{Board: board, UserID: score.UserID})
+		expected = append(expected, int64(score.Score))
+	}
+
+	levels, err := s.uRepo.SampleLevels(ctx, sampleSize)
+	if err != nil {
+		return nil, err
+	}
+	for _, level := range levels {
+		addLookup(entity.GlobalLeaderboard(), level)
+		if level.Country != "" {
+			addLookup(entity.CountryLeaderboard(level.Country), level)
+		}
+	}
+
+	sampled := len(levels)
+	if tournament, err := s.tRepo.GetActiveTournament(ctx); err == nil && tournament != nil {
+		scores, err := s.tuRepo.SampleTournamentScores(ctx, tournament.ID, sampleSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, score := range scores {
+			addLookup(entity.TournamentLeaderboard(score.TournamentID), score)
+			if score.GroupID != 0 {
+				addLookup(entity.TournamentGroupLeaderboard(score.TournamentID, score.GroupID), score)
+			}
+		}
+		sampled += len(scores)
+	}
+
+	actual, err := s.leaderboardStore.BatchScores(ctx, lookups)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &response.LeaderboardReconcileReport{Sampled: sampled}
+	for i, lookup := range lookups {
+		if actual[i] != nil && *actual[i] == expected[i] {
+			continue
+		}
+
+		report.Drifts = append(report.Drifts, response.LeaderboardDrift{
+			UserID:   lookup.UserID,
+			Key:      s.leaderboardStore.Key(lookup.Board),
+			Expected: expected[i],
+			Actual:   actual[i],
+		})
+
+		if fix && (actual[i] == nil || *actual[i] < expected[i]) {
+			if err := s.leaderboardStore.RaiseScore(ctx, lookup.UserID, expected[i], lookup.Board); err != nil {
+				return report, err
+			}
+			report.Fixed++
+		}
+	}
+	report.Drifted = len(report.Drifts)
+
+	if report.Drifted > 0 {
+		log.FromContext(ctx).Warnf("Leaderboard drift detected: sampled=%d, drifted=%d, fixed=%d",
+			report.Sampled, report.Drifted, report.Fixed)
+	} else {
+		log.FromContext(ctx).Infof("Leaderboard reconciliation found no drift in %d sampled entries", report.Sampled)
+	}
+
+	return report, nil
+}
+
+func (s *LeaderboardMaintenanceService) ReconcileWithConfig(ctx context.Context) (*response.LeaderboardReconcileReport, error) {
+	config := s.dynamicConfigService.GetConfig()
+	return s.Reconcile(ctx, config.ReconcileSampleSize, config.ReconcileAutoFix)
+}
+
+// UpgradeSchema migrates boards stored under an older key schema and, when the
+// schema changed, rebuilds them from Postgres. Instances that start together
+// share a Redis lock, so only one of them upgrades; the others return at once.
+func (s *LeaderboardMaintenanceService) UpgradeSchema(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, schemaUpgradeTimeout)
+	defer cancel()
+
+	owner := uuid.NewString()
+	locked, err := s.leaderboardStore.LockSchema(ctx, owner, schemaUpgradeTimeout)
+	if err != nil {
+		return err
+	}
+	if !locked {
+		log.FromContext(ctx).Info("Leaderboard schema upgrade is running elsewhere, skipping")
+		return nil
+	}
+	defer func() {
+		if err := s.leaderboardStore.UnlockSchema(context.WithoutCancel(ctx), owner); err != nil {
+			log.FromContext(ctx).WithError(err).Warn("Failed to release the leaderboard schema lock")
+		}
+	}()
+
+	upgraded, err := s.leaderboardStore.MigrateLegacyKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to migrate legacy leaderboard keys: %w", err)
+	}
+	if !upgraded {
+		return nil
+	}
+
+	log.FromContext(ctx).Info("Leaderboard key schema upgraded, rebuilding leaderboards")
+	if _, err := s.Rebuild(ctx, 0); err != nil {
+		return fmt.Errorf("failed to rebuild leaderboards after schema upgrade: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Sources coins are minted by and sinks they are burned by.
+const (
+	coinSourceLevelUp      = "level_up"
+	coinSourceReward       = "reward"
+	coinSourceAdmin        = "admin"
+	coinSinkTournamentFee  = "tournament_entry"
+	coinSinkAdmin          = "admin"
+	loginResultSuccess     = "success"
+	loginResultUnknownUser = "unknown_user"
+	loginResultBadPassword = "bad_password"
+	loginResultLocked      = "locked"
+)
+
+var (
+	registrations = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "goodblast_user_registrations_total",
+		Help: "Users registered.",
+	})
+
+	logins = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "goodblast_user_logins_total",
+		Help: "Login attempts by result.",
+	}, []string{"result"})
+
+	tournamentEntries = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "goodblast_tournament_entries_total",
+		Help: "Users who joined a tournament.",
+	})
+
+	progressEvents = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "goodblast_progress_events_total",
+		Help: "Levels completed by users.",
+	})
+
+	coinsMinted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "goodblast_coins_minted_total",
+		Help: "Coins added to user balances by source.",
+	}, []string{"source"})
+
+	coinsBurned = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "goodblast_coins_burned_total",
+		Help: "Coins removed from user balances by sink.",
+	}, []string{"sink"})
+
+	rewardsClaimed = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "goodblast_rewards_claimed_total",
+		Help: "Tournament rewards claimed.",
+	})
+)
+
+// recordCoinAdjustment counts a positive amount as minted and a negative one
+// as burned.
+func recordCoinAdjustment(amount int64, source, sink string) {
+	if amount > 0 {
+		coinsMinted.WithLabelValues(source).Add(float64(amount))
+	} else if amount < 0 {
+		coinsBurned.WithLabelValues(sink).Add(float64(-amount))
+	}
+}
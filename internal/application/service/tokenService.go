@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+	appconfig "goodblast/config"
+	"goodblast/internal/application/controller/response"
+	"goodblast/internal/application/repository"
+	"goodblast/internal/domain/entity"
+	domain "goodblast/internal/domain/errors"
+	"goodblast/pkg/auth"
+	"goodblast/pkg/log"
+	"time"
+)
+
+type ITokenService interface {
+	IssueTokens(ctx context.Context, user *entity.User) (*response.UserLoginResponse, error)
+	Refresh(ctx context.Context, refreshToken string) (*response.UserLoginResponse, error)
+	Logout(ctx context.Context, payload auth.TokenPayload, refreshToken string, allSessions bool) error
+}
+
+// TokenService issues short-lived access tokens together with rotating
+// refresh tokens. A refresh token can be used once; presenting it again
+// revokes every token descended from the same login and, since access tokens
+// do not record the login they descend from, every access token of the user.
+type TokenService struct {
+	db                   *bun.DB
+	tokenAuth            auth.IAuth
+	refreshTokenRepo     repository.IRefreshTokenRepository
+	uRepo                repository.IUserRepository
+	denylist             auth.ITokenDenylist
+	dynamicConfigService appconfig.IDynamicConfigService
+}
+
+func NewTokenService(
+	db *bun.DB,
+	tokenAuth auth.IAuth,
+	refreshTokenRepo repository.IRefreshTokenRepository,
+	uRepo repository.IUserRepository,
+	denylist auth.ITokenDenylist,
+	dynamicConfigService appconfig.IDynamicConfigService,
+) ITokenService {
+	return &TokenService{
+		db:                   db,
+		tokenAuth:            tokenAuth,
+		refreshTokenRepo:     refreshTokenRepo,
+		uRepo:                uRepo,
+		denylist:             denylist,
+		dynamicConfigService: dynamicConfigService,
+	}
+}
+
+func (s *TokenService) IssueTokens(ctx context.Context, user *entity.User) (*response.UserLoginResponse, error) {
+	refreshToken, refreshTokenRaw, err := s.newRefreshToken(user.ID, uuid.NewString())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refreshTokenRepo.Create(ctx, refreshToken); err != nil {
+		return nil, err
+	}
+
+	return s.tokenResponse(user, refreshToken, refreshTokenRaw)
+}
+
+func (s *TokenService) Refresh(ctx context.Context, refreshTokenRaw string) (*response.UserLoginResponse, error) {
+	var current, next *entity.RefreshToken
+	var nextRaw string
+	var reusedFamily string
+
+	err := s.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		var err error
+		current, err = s.refreshTokenRepo.FindByHashForUpdateTx(ctx, tx, auth.HashRefreshToken(refreshTokenRaw))
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return domain.ErrInvalidRefreshToken
+		}
+		if current.IsRevoked() {
+			reusedFamily = current.FamilyID
+			return domain.ErrInvalidRefreshToken
+		}
+		if current.IsExpired(time.Now().UTC()) {
+			return domain.ErrInvalidRefreshToken
+		}
+
+		next, nextRaw, err = s.newRefreshToken(current.UserID, current.FamilyID)
+		if err != nil {
+			return err
+		}
+		if err := s.refreshTokenRepo.CreateTx(ctx, tx, next); err != nil {
+			return err
+		}
+		return s.refreshTokenRepo.RotateTx(ctx, tx, current.ID, next.ID)
+	})
+
+	if reusedFamily != "" {
+		log.FromContext(ctx).Warnf("Refresh token reuse detected for user %d, revoking token family %s", current.UserID, reusedFamily)
+		if err := s.refreshTokenRepo.RevokeFamily(ctx, reusedFamily); err != nil {
+			log.FromContext(ctx).WithError(err).Errorf("Failed to revoke refresh token family %s", reusedFamily)
+		}
+		if err := s.denylist.RevokeUser(ctx, current.UserID, appconfig.MaxAccessTokenTTL); err != nil {
+			log.FromContext(ctx).WithError(err).Errorf("Failed to revoke the access tokens of user %d", current.UserID)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.uRepo.GetUserByID(ctx, current.UserID)
+	if err != nil || user == nil {
+		return nil, domain.ErrUserNotFound
+	}
+
+	return s.tokenResponse(user, next, nextRaw)
+}
+
+// Logout denylists the access token that made the request and revokes the
+// given refresh token. With allSessions every token of the user is revoked.
+// An access token without an ID cannot be denylisted alone, so every access
+// token of the user is revoked instead.
+func (s *TokenService) Logout(ctx context.Context, payload auth.TokenPayload, refreshTokenRaw string, allSessions bool) error {
+	if allSessions {
+		if err := s.refreshTokenRepo.RevokeAllForUser(ctx, payload.UserID); err != nil {
+			return err
+		}
+		return s.denylist.RevokeUser(ctx, payload.UserID, appconfig.MaxAccessTokenTTL)
+	}
+
+	if refreshTokenRaw != "" {
+		if err := s.refreshTokenRepo.RevokeByHash(ctx, payload.UserID, auth.HashRefreshToken(refreshTokenRaw)); err != nil {
+			return err
+		}
+	}
+	if payload.TokenID == "" {
+		log.FromContext(ctx).Warnf("Access token of user %d has no id, revoking every access token of the user", payload.UserID)
+		return s.denylist.RevokeUser(ctx, payload.UserID, appconfig.MaxAccessTokenTTL)
+	}
+	return s.denylist.Revoke(ctx, payload)
+}
+
+func (s *TokenService) newRefreshToken(userID int64, familyID string) (*entity.RefreshToken, string, error) {
+	raw, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &entity.RefreshToken{
+		UserID:    userID,
+		TokenHash: hash,
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().UTC().Add(s.dynamicConfigService.GetConfig().RefreshTokenTTL()),
+	}, raw, nil
+}
+
+func (s *TokenService) tokenResponse(user *entity.User, refreshToken *entity.RefreshToken, refreshTokenRaw string) (*response.UserLoginResponse, error) {
+	accessToken, err := s.tokenAuth.GenerateToken(user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.UserLoginResponse{
+		Token:            accessToken,
+		ExpiresAt:        time.Now().UTC().Add(s.tokenAuth.TokenTTL()),
+		RefreshToken:     refreshTokenRaw,
+		RefreshExpiresAt: refreshToken.ExpiresAt,
+	}, nil
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
-	"fmt"
 	ckafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/uptrace/bun"
 	appconfig "goodblast/config"
@@ -140,12 +139,12 @@ func (s *TournamentService) EnterTournamentAsync(ctx context.Context, userID int
 		return err
 	}
 
-	err = kafkautil.ProduceFireAndForget(s.producer, s.dynamicConfigService.GetConfig().TournamentEntryTopic, data)
+	err = kafkautil.ProduceFireAndForget(ctx, s.producer, s.dynamicConfigService.GetConfig().TournamentEntryTopic, data)
 	if err != nil {
 		return err
 	}
 
-	log.GetLogger().Infof(
+	log.FromContext(ctx).Infof(
 		"Fire-and-forget message for userID=%d has been produced to topic=%s (no delivery check).",
 		userID, s.dynamicConfigService.GetConfig().TournamentEntryTopic,
 	)
@@ -162,6 +161,8 @@ func (s *TournamentService) EnterTournament(ctx context.Context, userID int64) e
 	if tournament == nil {
 		return domainErr.ErrNoActiveTournament
 	}
+	ctx = log.WithTournamentID(ctx, tournament.ID)
+	tournamentEntranceCoins := int64(s.dynamicConfigService.GetConfig().TournamentEntranceCoins)
 
 	err = s.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
 		user, err := s.uRepo.FindUserForUpdateTx(ctx, tx, userID)
@@ -172,8 +173,6 @@ func (s *TournamentService) EnterTournament(ctx context.Context, userID int64) e
 			return domainErr.ErrUserNotFound
 		}
 
-		tournamentEntranceCoins := int64(s.dynamicConfigService.GetConfig().TournamentEntranceCoins)
-
 		user.Coins -= tournamentEntranceCoins
 		if err := s.uRepo.UpdateUserTx(ctx, tx, user); err != nil {
 			return err
@@ -230,7 +229,9 @@ func (s *TournamentService) EnterTournament(ctx context.Context, userID int64) e
 		return err
 	}
 
-	log.GetLogger().Info(fmt.Sprintf("User %d joined tournament %d in group ???", userID, tournament.ID))
+	tournamentEntries.Inc()
+	recordCoinAdjustment(-tournamentEntranceCoins, "", coinSinkTournamentFee)
+	log.FromContext(ctx).Infof("User %d joined tournament %d in group ???", userID, tournament.ID)
 	return nil
 }
 
@@ -242,6 +243,7 @@ func (s *TournamentService) UpdateTournamentScore(ctx context.Context, message e
 	if tournament == nil {
 		return nil
 	}
+	ctx = log.WithTournamentID(ctx, tournament.ID)
 
 	tournamentUser, err := s.tuRepo.GetTournamentUser(ctx, tournament.ID, message.UserID)
 	if err != nil {
@@ -260,6 +262,7 @@ func (s *TournamentService) UpdateTournamentScore(ctx context.Context, message e
 	payload := events.LeaderboardUpdateMessage{
 		UserID:       message.UserID,
 		TournamentID: tournament.ID,
+		GroupID:      tournamentUser.GroupID,
 		Country:      message.Country,
 		Score:        tournamentUser.Score,
 	}
@@ -269,9 +272,9 @@ func (s *TournamentService) UpdateTournamentScore(ctx context.Context, message e
 		return err
 	}
 
-	err = kafkautil.ProduceFireAndForget(s.producer, s.dynamicConfigService.GetConfig().LeaderboardUpdateTopic, data)
+	err = kafkautil.ProduceFireAndForget(ctx, s.producer, s.dynamicConfigService.GetConfig().LeaderboardUpdateTopic, data)
 	if err != nil {
-		log.GetLogger().Error(fmt.Sprintf("Failed to send leaderboard update for user %d to Kafka: %v", message.UserID, err))
+		log.FromContext(ctx).WithError(err).Errorf("Failed to send leaderboard update for user %d to Kafka", message.UserID)
 	}
 
 	return nil
@@ -290,10 +293,13 @@ func (s *TournamentService) StoreRewards(ctx context.Context, tournamentID int64
 		tournamentUsers = tournamentUsers[:10]
 	}
 
-	reward1 := s.dynamicConfigService.GetConfig().Reward1
-	reward2 := s.dynamicConfigService.GetConfig().Reward2
-	reward3 := s.dynamicConfigService.GetConfig().Reward3
-	reward4to10 := s.dynamicConfigService.GetConfig().Reward4to10
+	// Read the rewards from a single snapshot so a reload in between cannot
+	// mix old and new values.
+	config := s.dynamicConfigService.GetConfig()
+	reward1 := config.Reward1
+	reward2 := config.Reward2
+	reward3 := config.Reward3
+	reward4to10 := config.Reward4to10
 
 	var rewards []entity.TournamentReward
 
@@ -363,7 +369,9 @@ func (s *TournamentService) ClaimReward(ctx context.Context, userID int64) error
 		if err := s.tournamentRewardRepo.ClaimReward(ctx, rw.ID); err != nil {
 			return err
 		}
+		rewardsClaimed.Inc()
 	}
+	recordCoinAdjustment(totalCoins, coinSourceReward, "")
 
 	return nil
 }
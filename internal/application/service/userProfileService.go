@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/redis/go-redis/v9"
+	"goodblast/internal/application/repository"
+	"goodblast/internal/domain/entity"
+	"goodblast/pkg/log"
+	"strconv"
+	"time"
+)
+
+// Profiles are cached under one key per user, so profiles of users who stop
+// showing up on leaderboards expire instead of piling up. A whole leaderboard
+// page is still resolved with one MGET.
+const (
+	userProfileCachePrefix = "user:profile:"
+	userProfileCacheTTL    = 24 * time.Hour
+)
+
+type IUserProfileService interface {
+	GetProfiles(ctx context.Context, userIDs []int64) (map[int64]entity.UserProfile, error)
+	RefreshProfile(ctx context.Context, user *entity.User) error
+}
+
+type UserProfileService struct {
+	redisClient    *redis.Client
+	userRepository repository.IUserRepository
+}
+
+func NewUserProfileService(redisClient *redis.Client, userRepository repository.IUserRepository) IUserProfileService {
+	return &UserProfileService{
+		redisClient:    redisClient,
+		userRepository: userRepository,
+	}
+}
+
+func (s *UserProfileService) GetProfiles(ctx context.Context, userIDs []int64) (map[int64]entity.UserProfile, error) {
+	profiles := make(map[int64]entity.UserProfile, len(userIDs))
+	if len(userIDs) == 0 {
+		return profiles, nil
+	}
+
+	keys := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		keys[i] = userProfileCacheKey(userID)
+	}
+
+	var missing []int64
+	values, err := s.redisClient.MGet(ctx, keys...).Result()
+	if err != nil {
+		log.FromContext(ctx).WithError(err).Warn("Failed to read user profile cache, falling back to database")
+		missing = userIDs
+	} else {
+		for i, value := range values {
+			raw, ok := value.(string)
+			if !ok {
+				missing = append(missing, userIDs[i])
+				continue
+			}
+
+			var profile entity.UserProfile
+			if err := json.Unmarshal([]byte(raw), &profile); err != nil {
+				missing = append(missing, userIDs[i])
+				continue
+			}
+			profiles[profile.UserID] = profile
+		}
+	}
+
+	if len(missing) == 0 {
+		return profiles, nil
+	}
+
+	users, err := s.userRepository.GetUsersByIDs(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	pipe := s.redisClient.Pipeline()
+	for i := range users {
+		profile := entity.NewUserProfile(&users[i])
+		profiles[profile.UserID] = profile
+
+		data, err := json.Marshal(profile)
+		if err != nil {
+			continue
+		}
+		pipe.Set(ctx, userProfileCacheKey(profile.UserID), data, userProfileCacheTTL)
+	}
+
+	if pipe.Len() > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			log.FromContext(ctx).WithError(err).Warn("Failed to populate user profile cache")
+		}
+	}
+
+	return profiles, nil
+}
+
+func (s *UserProfileService) RefreshProfile(ctx context.Context, user *entity.User) error {
+	data, err := json.Marshal(entity.NewUserProfile(user))
+	if err != nil {
+		return err
+	}
+	return s.redisClient.Set(ctx, userProfileCacheKey(user.ID), data, userProfileCacheTTL).Err()
+}
+
+func userProfileCacheKey(userID int64) string {
+	return userProfileCachePrefix + strconv.FormatInt(userID, 10)
+}
@@ -12,19 +12,23 @@ import (
 	domain "goodblast/internal/domain/errors"
 	"goodblast/internal/domain/events"
 	kafkautil "goodblast/internal/infrastructure/kafka/producer"
+	"goodblast/pkg/auth"
 	"goodblast/pkg/log"
 )
 
 type IUserService interface {
 	CreateUser(ctx context.Context, userRequest request.CreateUserRequest) (*int64, error)
-	Login(ctx context.Context, userRequest request.UserLoginRequest) (*entity.User, error)
+	Login(ctx context.Context, userRequest request.UserLoginRequest, clientIP string) (*entity.User, error)
 	UpdateProgress(ctx context.Context, userID int64) error
+	GrantCoins(ctx context.Context, userID int64, amount int64) (*entity.User, error)
 }
 
 type UserService struct {
 	userRepository           repository.IUserRepository
 	tournamentRepository     repository.ITournamentRepository
 	tournamentUserRepository repository.ITournamentUserRepository
+	userProfileService       IUserProfileService
+	loginLockout             auth.ILoginLockout
 	dynamicConfigService     appconfig.IDynamicConfigService
 	producer                 *ckafka.Producer
 }
@@ -33,6 +37,8 @@ func NewUserService(
 	userRepository repository.IUserRepository,
 	tournamentRepository repository.ITournamentRepository,
 	tournamentUserRepository repository.ITournamentUserRepository,
+	userProfileService IUserProfileService,
+	loginLockout auth.ILoginLockout,
 	dynamicConfigService appconfig.IDynamicConfigService,
 	producer *ckafka.Producer,
 
@@ -41,6 +47,8 @@ func NewUserService(
 		userRepository:           userRepository,
 		tournamentRepository:     tournamentRepository,
 		tournamentUserRepository: tournamentUserRepository,
+		userProfileService:       userProfileService,
+		loginLockout:             loginLockout,
 		dynamicConfigService:     dynamicConfigService,
 		producer:                 producer,
 	}
@@ -52,29 +60,88 @@ func (usrServ *UserService) CreateUser(ctx context.Context, userRequest request.
 	userId, err := usrServ.userRepository.CreateUser(ctx, &user)
 
 	if err != nil {
-		log.GetLogger().Error(fmt.Sprintf("UserService.CreateUser - Error: %v", err.Error()))
+		log.FromContext(ctx).WithError(err).Error("UserService.CreateUser - Error")
 		return nil, domain.ErrUserAlreadyExists
 	}
+	registrations.Inc()
+
+	if err := usrServ.userProfileService.RefreshProfile(ctx, &user); err != nil {
+		log.FromContext(ctx).WithError(err).Warnf("Failed to cache profile for user %d", userId)
+	}
 
 	return &userId, nil
 }
 
-func (usrServ *UserService) Login(ctx context.Context, userRequest request.UserLoginRequest) (*entity.User, error) {
+func (usrServ *UserService) Login(ctx context.Context, userRequest request.UserLoginRequest, clientIP string) (*entity.User, error) {
 	user, err := usrServ.userRepository.GetUserByUsername(ctx, userRequest.Username)
 
 	if err != nil {
-		log.GetLogger().Error(fmt.Sprintf("UserService.Login - Error: %v", err.Error()))
+		log.FromContext(ctx).WithError(err).Error("UserService.Login - Error")
+		return nil, domain.ErrUserNotFound
+	}
+	if user == nil {
+		logins.WithLabelValues(loginResultUnknownUser).Inc()
 		return nil, domain.ErrUserNotFound
 	}
 
+	// The lockout fails open: a Redis outage must not lock everyone out.
+	lockout := usrServ.dynamicConfigService.GetConfig().LoginLockout
+	if lockout.Enabled {
+		lockedFor, err := usrServ.loginLockout.LockedFor(ctx, user.Username, clientIP)
+		if err != nil {
+			log.FromContext(ctx).WithError(err).Warnf("Failed to check the login lockout of user %d", user.ID)
+		} else if lockedFor > 0 {
+			logins.WithLabelValues(loginResultLocked).Inc()
+			return nil, domain.NewRetryableError(domain.ErrAccountLocked, lockedFor)
+		}
+	}
+
 	if err := user.CheckPassword(userRequest.Password); err != nil {
-		log.GetLogger().Error(fmt.Sprintf("UserService.Login - Error: %v, userId: %v", err.Error(), user.ID))
+		log.FromContext(ctx).WithError(err).Errorf("UserService.Login - Error, userId: %d", user.ID)
+		logins.WithLabelValues(loginResultBadPassword).Inc()
+		if lockout.Enabled {
+			usrServ.recordLoginFailure(ctx, user, clientIP, lockout)
+		}
 		return nil, domain.ErrInvalidPassword
 	}
 
+	if lockout.Enabled {
+		if err := usrServ.loginLockout.Reset(ctx, user.Username, clientIP); err != nil {
+			log.FromContext(ctx).WithError(err).Warnf("Failed to clear the failed logins of user %d", user.ID)
+		}
+	}
+
+	logins.WithLabelValues(loginResultSuccess).Inc()
 	return user, nil
 }
 
+// recordLoginFailure counts a wrong password and locks the account for the IP
+// once its failures reach the lockout threshold, and for every IP once the
+// failures from all of them reach the higher account threshold.
+func (usrServ *UserService) recordLoginFailure(ctx context.Context, user *entity.User, clientIP string, lockout appconfig.LoginLockout) {
+	failures, err := usrServ.loginLockout.RecordFailure(ctx, user.Username, clientIP, lockout.FailureWindow())
+	if err != nil {
+		log.FromContext(ctx).WithError(err).Warnf("Failed to record a failed login of user %d", user.ID)
+		return
+	}
+
+	if lockedFor := lockout.LockoutFor(failures.FromIP); lockedFor > 0 {
+		if err := usrServ.loginLockout.Lock(ctx, user.Username, clientIP, lockedFor); err != nil {
+			log.FromContext(ctx).WithError(err).Warnf("Failed to lock user %d", user.ID)
+		} else {
+			log.FromContext(ctx).Warnf("Locked user %d for %s from %s after %d failed logins", user.ID, lockedFor, clientIP, failures.FromIP)
+		}
+	}
+
+	if lockedFor := lockout.AccountLockoutFor(failures.Account); lockedFor > 0 {
+		if err := usrServ.loginLockout.LockAccount(ctx, user.Username, lockedFor); err != nil {
+			log.FromContext(ctx).WithError(err).Warnf("Failed to lock user %d", user.ID)
+		} else {
+			log.FromContext(ctx).Warnf("Locked user %d for %s from every IP after %d failed logins", user.ID, lockedFor, failures.Account)
+		}
+	}
+}
+
 func (usrServ *UserService) UpdateProgress(ctx context.Context, userID int64) error {
 	user, err := usrServ.userRepository.GetUserByID(ctx, userID)
 	if err != nil || user == nil {
@@ -87,17 +154,54 @@ func (usrServ *UserService) UpdateProgress(ctx context.Context, userID int64) er
 	if err != nil {
 		return domain.ErrInternalServerError
 	}
+	progressEvents.Inc()
+	recordCoinAdjustment(int64(coinPerLevel), coinSourceLevelUp, "")
+
+	if err := usrServ.userProfileService.RefreshProfile(ctx, user); err != nil {
+		log.FromContext(ctx).WithError(err).Warnf("Failed to refresh profile for user %d", user.ID)
+	}
 
-	payload := events.ProgressUpdateMessage{UserID: userID, Country: user.Country}
+	payload := events.ProgressUpdateMessage{UserID: userID, Country: user.Country, Level: user.Level}
 	data, err := json.Marshal(payload)
 	if err != nil {
 		return err
 	}
 
-	err = kafkautil.ProduceFireAndForget(usrServ.producer, usrServ.dynamicConfigService.GetConfig().UserProgressUpdateTopic, data)
+	err = kafkautil.ProduceFireAndForget(ctx, usrServ.producer, usrServ.dynamicConfigService.GetConfig().UserProgressUpdateTopic, data)
 	if err != nil {
-		log.GetLogger().Error(fmt.Sprintf("Failed to send progress update for user %d to Kafka: %v", user.ID, err))
+		log.FromContext(ctx).WithError(err).Errorf("Failed to send progress update for user %d to Kafka", user.ID)
 	}
 
 	return nil
 }
+
+func (usrServ *UserService) GrantCoins(ctx context.Context, userID int64, amount int64) (*entity.User, error) {
+	user, err := usrServ.userRepository.AddCoins(ctx, userID, amount)
+	if err != nil {
+		log.FromContext(ctx).WithError(err).Errorf("UserService.GrantCoins - Error, userId: %d", userID)
+		return nil, domain.ErrInternalServerError
+	}
+	if user == nil {
+		return nil, usrServ.coinAdjustmentRejected(ctx, userID, amount)
+	}
+	recordCoinAdjustment(amount, coinSourceAdmin, coinSinkAdmin)
+
+	if err := usrServ.userProfileService.RefreshProfile(ctx, user); err != nil {
+		log.FromContext(ctx).WithError(err).Warnf("Failed to refresh profile for user %d", user.ID)
+	}
+	return user, nil
+}
+
+// coinAdjustmentRejected tells why AddCoins changed nothing: the user does not
+// exist or does not have the coins to deduct.
+func (usrServ *UserService) coinAdjustmentRejected(ctx context.Context, userID int64, amount int64) error {
+	user, err := usrServ.userRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		log.FromContext(ctx).WithError(err).Errorf("UserService.GrantCoins - Error, userId: %d", userID)
+		return domain.ErrInternalServerError
+	}
+	if user == nil {
+		return domain.ErrUserNotFound
+	}
+	return domain.ErrInsufficientCoins.WithDetail(fmt.Sprintf("user %d has %d coins, cannot deduct %d", userID, user.Coins, -amount))
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+	appconfig "goodblast/config"
+	"goodblast/internal/application/controller/request"
+	"goodblast/internal/application/repository"
+	"goodblast/internal/domain/entity"
+	domain "goodblast/internal/domain/errors"
+	"goodblast/pkg/auth"
+	"testing"
+	"time"
+)
+
+const (
+	lockoutTestUsername = "player"
+	lockoutTestPassword = "correct-password"
+)
+
+var lockoutTestPolicy = appconfig.LoginLockout{
+	Enabled:              true,
+	MaxFailures:          3,
+	MaxAccountFailures:   6,
+	FailureWindowSeconds: 900,
+	BaseLockoutSeconds:   30,
+	MaxLockoutSeconds:    3600,
+}
+
+// Failures from one IP lock the account for that IP only.
+func TestLoginLocksAccountForIP(t *testing.T) {
+	service, lockout := newLockoutTestService(t)
+
+	for i := 0; i < lockoutTestPolicy.MaxFailures; i++ {
+		assertLoginError(t, service, "10.0.0.1", "wrong", domain.ErrInvalidPassword)
+	}
+	if got := lockout.locks["player:10.0.0.1"]; got != 30*time.Second {
+		t.Fatalf("lock for the IP = %s, want 30s", got)
+	}
+
+	// The IP stays locked even with the right password, and every further
+	// failure from it doubles the lock.
+	assertLoginError(t, service, "10.0.0.1", lockoutTestPassword, domain.ErrAccountLocked)
+	lockout.locks["player:10.0.0.1"] = 0
+	assertLoginError(t, service, "10.0.0.1", "wrong", domain.ErrInvalidPassword)
+	if got := lockout.locks["player:10.0.0.1"]; got != time.Minute {
+		t.Fatalf("lock for the IP after another failure = %s, want 1m", got)
+	}
+
+	// The owner can still log in from another address.
+	if _, err := service.Login(context.Background(), loginRequest(lockoutTestPassword), "10.0.0.2"); err != nil {
+		t.Fatalf("Login() from another IP = %v, want nil", err)
+	}
+	if _, ok := lockout.locks["player"]; ok {
+		t.Fatal("the account should not be locked for every IP")
+	}
+}
+
+// Failures spread over many IPs, each below the per-IP threshold, lock the
+// account for every IP once they reach the account threshold.
+func TestLoginLocksAccountForEveryIP(t *testing.T) {
+	service, lockout := newLockoutTestService(t)
+
+	for i := 0; i < lockoutTestPolicy.MaxAccountFailures; i++ {
+		assertLoginError(t, service, fmt.Sprintf("10.0.1.%d", i), "wrong", domain.ErrInvalidPassword)
+	}
+	if got := lockout.locks["player"]; got != 30*time.Second {
+		t.Fatalf("lock for the account = %s, want 30s", got)
+	}
+	for ip, lockedFor := range lockout.locks {
+		if ip != "player" && lockedFor > 0 {
+			t.Fatalf("no single IP should be locked, %s is for %s", ip, lockedFor)
+		}
+	}
+
+	assertLoginError(t, service, "10.0.2.1", lockoutTestPassword, domain.ErrAccountLocked)
+
+	// A failure after the lock ends doubles it.
+	lockout.locks["player"] = 0
+	assertLoginError(t, service, "10.0.2.1", "wrong", domain.ErrInvalidPassword)
+	if got := lockout.locks["player"]; got != time.Minute {
+		t.Fatalf("lock for the account after another failure = %s, want 1m", got)
+	}
+}
+
+// A successful login clears the failures from its IP but not those of the
+// account, so the owner logging in does not reset an attack from elsewhere.
+func TestLoginSuccessKeepsAccountFailures(t *testing.T) {
+	service, lockout := newLockoutTestService(t)
+
+	assertLoginError(t, service, "10.0.0.1", "wrong", domain.ErrInvalidPassword)
+	assertLoginError(t, service, "10.0.0.2", "wrong", domain.ErrInvalidPassword)
+	if _, err := service.Login(context.Background(), loginRequest(lockoutTestPassword), "10.0.0.1"); err != nil {
+		t.Fatalf("Login() = %v, want nil", err)
+	}
+
+	if got := lockout.failures["player:10.0.0.1"]; got != 0 {
+		t.Fatalf("failures from the IP after a successful login = %d, want 0", got)
+	}
+	if got := lockout.failures["player"]; got != 2 {
+		t.Fatalf("failures of the account after a successful login = %d, want 2", got)
+	}
+}
+
+func newLockoutTestService(t *testing.T) (IUserService, *memoryLoginLockout) {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(lockoutTestPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lockout := &memoryLoginLockout{failures: make(map[string]int), locks: make(map[string]time.Duration)}
+	users := &lockoutTestUserRepository{user: &entity.User{ID: 1, Username: lockoutTestUsername, PasswordHash: string(hash)}}
+	config := &lockoutTestConfigService{config: appconfig.DynamicConfig{LoginLockout: lockoutTestPolicy}}
+	return NewUserService(users, nil, nil, nil, lockout, config, nil), lockout
+}
+
+func loginRequest(password string) request.UserLoginRequest {
+	return request.UserLoginRequest{Username: lockoutTestUsername, Password: password}
+}
+
+func assertLoginError(t *testing.T, service IUserService, clientIP, password string, want error) {
+	t.Helper()
+	if _, err := service.Login(context.Background(), loginRequest(password), clientIP); !errors.Is(err, want) {
+		t.Fatalf("Login() from %s = %v, want %v", clientIP, err, want)
+	}
+}
+
+type lockoutTestUserRepository struct {
+	repository.IUserRepository
+	user *entity.User
+}
+
+func (r *lockoutTestUserRepository) GetUserByUsername(ctx context.Context, username string) (*entity.User, error) {
+	if username != r.user.Username {
+		return nil, nil
+	}
+	return r.user, nil
+}
+
+type lockoutTestConfigService struct {
+	appconfig.IDynamicConfigService
+	config appconfig.DynamicConfig
+}
+
+func (s *lockoutTestConfigService) GetConfig() appconfig.DynamicConfig {
+	return s.config
+}
+
+// memoryLoginLockout keeps the keys of RedisLoginLockout without expiry: a
+// lock holds its length until a test sets it to zero.
+type memoryLoginLockout struct {
+	failures map[string]int
+	locks    map[string]time.Duration
+}
+
+func (l *memoryLoginLockout) LockedFor(ctx context.Context, username, clientIP string) (time.Duration, error) {
+	return max(l.locks[username+":"+clientIP], l.locks[username]), nil
+}
+
+func (l *memoryLoginLockout) RecordFailure(ctx context.Context, username, clientIP string, failureWindow time.Duration) (auth.LoginFailures, error) {
+	l.failures[username+":"+clientIP]++
+	l.failures[username]++
+	return auth.LoginFailures{FromIP: l.failures[username+":"+clientIP], Account: l.failures[username]}, nil
+}
+
+func (l *memoryLoginLockout) Lock(ctx context.Context, username, clientIP string, lockout time.Duration) error {
+	l.locks[username+":"+clientIP] = lockout
+	return nil
+}
+
+func (l *memoryLoginLockout) LockAccount(ctx context.Context, username string, lockout time.Duration) error {
+	l.locks[username] = lockout
+	return nil
+}
+
+func (l *memoryLoginLockout) Reset(ctx context.Context, username, clientIP string) error {
+	delete(l.failures, username+":"+clientIP)
+	delete(l.locks, username+":"+clientIP)
+	return nil
+}
@@ -0,0 +1,127 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+type LeaderboardType string
+
+const (
+	LeaderboardTypeGlobal     LeaderboardType = "global"
+	LeaderboardTypeCountry    LeaderboardType = "country"
+	LeaderboardTypeTournament LeaderboardType = "tournament"
+)
+
+type LeaderboardPeriod string
+
+const (
+	LeaderboardPeriodAllTime LeaderboardPeriod = "alltime"
+	LeaderboardPeriodDaily   LeaderboardPeriod = "daily"
+	LeaderboardPeriodWeekly  LeaderboardPeriod = "weekly"
+)
+
+// Leaderboard identifies a single board. Global and country boards can be
+// bucketed by Period; At selects the bucket and defaults to now.
+type Leaderboard struct {
+	Type         LeaderboardType
+	Country      string
+	TournamentID int64
+	GroupID      int64
+	Period       LeaderboardPeriod
+	At           time.Time
+}
+
+func GlobalLeaderboard() Leaderboard {
+	return Leaderboard{Type: LeaderboardTypeGlobal, Period: LeaderboardPeriodAllTime}
+}
+
+func CountryLeaderboard(country string) Leaderboard {
+	return Leaderboard{Type: LeaderboardTypeCountry, Country: country, Period: LeaderboardPeriodAllTime}
+}
+
+func TournamentLeaderboard(tournamentID int64) Leaderboard {
+	return Leaderboard{Type: LeaderboardTypeTournament, TournamentID: tournamentID, Period: LeaderboardPeriodAllTime}
+}
+
+func TournamentGroupLeaderboard(tournamentID, groupID int64) Leaderboard {
+	board := TournamentLeaderboard(tournamentID)
+	board.GroupID = groupID
+	return board
+}
+
+func (l Leaderboard) ForPeriod(period LeaderboardPeriod, at time.Time) Leaderboard {
+	l.Period = period
+	l.At = at
+	return l
+}
+
+func (l Leaderboard) IsPeriodic() bool {
+	return l.Period == LeaderboardPeriodDaily || l.Period == LeaderboardPeriodWeekly
+}
+
+// Bucket returns the period identifier used in the board key, e.g.
+// "2025-02-14" for daily or "2025-W07" for weekly boards.
+func (l Leaderboard) Bucket() string {
+	at := l.bucketTime()
+	switch l.Period {
+	case LeaderboardPeriodDaily:
+		return at.Format(time.DateOnly)
+	case LeaderboardPeriodWeekly:
+		year, week := at.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	default:
+		return ""
+	}
+}
+
+// PeriodEnd returns when the bucket containing At closes.
+func (l Leaderboard) PeriodEnd() time.Time {
+	at := l.bucketTime()
+	startOfDay := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+	switch l.Period {
+	case LeaderboardPeriodDaily:
+		return startOfDay.AddDate(0, 0, 1)
+	case LeaderboardPeriodWeekly:
+		daysSinceMonday := (int(startOfDay.Weekday()) + 6) % 7
+		return startOfDay.AddDate(0, 0, 7-daysSinceMonday)
+	default:
+		return time.Time{}
+	}
+}
+
+// Retention is how long a periodic board is kept after its period closes so
+// that the previous day or week stays readable.
+func (l Leaderboard) Retention() time.Duration {
+	switch l.Period {
+	case LeaderboardPeriodDaily:
+		return 24 * time.Hour
+	case LeaderboardPeriodWeekly:
+		return 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+func (l Leaderboard) bucketTime() time.Time {
+	if l.At.IsZero() {
+		return time.Now().UTC()
+	}
+	return l.At.UTC()
+}
+
+// LeaderboardScore is a user's score as recorded in Postgres, used to rebuild
+// and reconcile the Redis leaderboards.
+type LeaderboardScore struct {
+	UserID       int64  `bun:"user_id"`
+	Country      string `bun:"country"`
+	TournamentID int64  `bun:"tournament_id"`
+	GroupID      int64  `bun:"group_id"`
+	Score        int    `bun:"score"`
+}
+
+type LeaderboardMember struct {
+	UserID int64
+	Score  int64
+	Rank   int64
+}
@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+// RefreshToken is a single-use token that can be exchanged for a new access
+// token. Only a hash of the token is stored. Tokens minted from the same login
+// share a FamilyID so the whole chain can be revoked when a used token is
+// presented again.
+type RefreshToken struct {
+	ID           int64      `bun:"id,pk,autoincrement"`
+	UserID       int64      `bun:"user_id,notnull"`
+	TokenHash    string     `bun:"token_hash,unique,notnull"`
+	FamilyID     string     `bun:"family_id,type:uuid,notnull"`
+	ExpiresAt    time.Time  `bun:"expires_at,notnull"`
+	RevokedAt    *time.Time `bun:"revoked_at"`
+	ReplacedByID *int64     `bun:"replaced_by_id"`
+	CreatedAt    time.Time  `bun:"created_at,notnull,default:current_timestamp"`
+}
+
+func (t *RefreshToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+func (t *RefreshToken) IsExpired(now time.Time) bool {
+	return now.After(t.ExpiresAt)
+}
@@ -3,15 +3,25 @@ package entity
 import (
 	"golang.org/x/crypto/bcrypt"
 	"goodblast/internal/application/controller/request"
+	"strings"
+)
+
+type UserRole string
+
+const (
+	UserRolePlayer  UserRole = "player"
+	UserRoleAdmin   UserRole = "admin"
+	UserRoleService UserRole = "service"
 )
 
 type User struct {
-	ID           int64  `bun:"id,pk,autoincrement"`
-	Username     string `bun:"username,unique,notnull"`
-	PasswordHash string `bun:"password_hash,notnull"`
-	Coins        int64  `bun:"coins,default:1000"`
-	Level        int    `bun:"level,default:1"`
-	Country      string `bun:"country"`
+	ID           int64    `bun:"id,pk,autoincrement"`
+	Username     string   `bun:"username,unique,notnull"`
+	PasswordHash string   `bun:"password_hash,notnull"`
+	Coins        int64    `bun:"coins,default:1000"`
+	Level        int      `bun:"level,default:1"`
+	Country      string   `bun:"country"`
+	Role         UserRole `bun:"role,type:text,default:'player'"`
 }
 
 func NewUserFromRequest(request request.CreateUserRequest) User {
@@ -24,7 +34,8 @@ func NewUserFromRequest(request request.CreateUserRequest) User {
 		PasswordHash: hashedPassword,
 		Coins:        1000,
 		Level:        1,
-		Country:      request.Country,
+		Country:      strings.ToUpper(request.Country),
+		Role:         UserRolePlayer,
 	}
 }
 
@@ -0,0 +1,17 @@
+package entity
+
+type UserProfile struct {
+	UserID   int64  `json:"userId"`
+	Username string `json:"username"`
+	Country  string `json:"country"`
+	Level    int    `json:"level"`
+}
+
+func NewUserProfile(user *User) UserProfile {
+	return UserProfile{
+		UserID:   user.ID,
+		Username: user.Username,
+		Country:  user.Country,
+		Level:    user.Level,
+	}
+}
@@ -1,26 +1,84 @@
 package domain
 
-import "net/http"
+import (
+	"net/http"
+	"time"
+)
 
+// CustomError is an error the API reports to clients. Code is stable and
+// machine-readable, Message summarizes the kind of error and Detail, when set,
+// describes this occurrence.
 type CustomError struct {
+	Code    string
 	Message string
 	Status  int
+	Detail  string
 }
 
 func (e *CustomError) Error() string {
+	if e.Detail != "" {
+		return e.Message + ": " + e.Detail
+	}
 	return e.Message
 }
 
+// Is matches errors with the same code, so errors.Is recognizes a copy made
+// by WithDetail as the error it was made from.
+func (e *CustomError) Is(target error) bool {
+	customErr, ok := target.(*CustomError)
+	return ok && customErr.Code == e.Code
+}
+
+// WithDetail returns a copy of the error describing one occurrence of it.
+func (e *CustomError) WithDetail(detail string) *CustomError {
+	copied := *e
+	copied.Detail = detail
+	return &copied
+}
+
+// RetryableError is a CustomError the client may retry once RetryAfter has
+// passed, reported in the Retry-After header.
+type RetryableError struct {
+	*CustomError
+	RetryAfter time.Duration
+}
+
+func NewRetryableError(err *CustomError, retryAfter time.Duration) *RetryableError {
+	return &RetryableError{CustomError: err, RetryAfter: retryAfter}
+}
+
+func (e *RetryableError) Unwrap() error {
+	return e.CustomError
+}
+
 var (
-	ErrUserNotFound                 = &CustomError{"user not found", http.StatusNotFound}
-	ErrInvalidPassword              = &CustomError{"invalid password", http.StatusUnauthorized}
-	ErrUserAlreadyExists            = &CustomError{"user already exists", http.StatusConflict}
-	ErrInternalServerError          = &CustomError{"internal server error", http.StatusInternalServerError}
-	ErrTournamentNotFound           = &CustomError{"tournament not found", http.StatusNotFound}
-	ErrTournamentAlreadyEnded       = &CustomError{"tournament has already ended or closed", http.StatusConflict}
-	ErrNoActiveTournament           = &CustomError{"no active tournament", http.StatusNotFound}
-	ErrLevelTooLowToEnterTournament = &CustomError{"level too low to enter", http.StatusForbidden}
-	ErrInsufficientCoins            = &CustomError{"insufficient coins", http.StatusForbidden}
-	ErrTournamentRegistrationClosed = &CustomError{"tournament registration closed", http.StatusConflict}
-	ErrNoUnclaimedReward            = &CustomError{"no unclaimed reward", http.StatusNotFound}
+	ErrUserNotFound                 = &CustomError{Code: "USER_NOT_FOUND", Message: "user not found", Status: http.StatusNotFound}
+	ErrInvalidPassword              = &CustomError{Code: "INVALID_PASSWORD", Message: "invalid password", Status: http.StatusUnauthorized}
+	ErrUserAlreadyExists            = &CustomError{Code: "USER_ALREADY_EXISTS", Message: "user already exists", Status: http.StatusConflict}
+	ErrInternalServerError          = &CustomError{Code: "INTERNAL_ERROR", Message: "internal server error", Status: http.StatusInternalServerError}
+	ErrTournamentNotFound           = &CustomError{Code: "TOURNAMENT_NOT_FOUND", Message: "tournament not found", Status: http.StatusNotFound}
+	ErrTournamentAlreadyEnded       = &CustomError{Code: "TOURNAMENT_ALREADY_ENDED", Message: "tournament has already ended or closed", Status: http.StatusConflict}
+	ErrNoActiveTournament           = &CustomError{Code: "NO_ACTIVE_TOURNAMENT", Message: "no active tournament", Status: http.StatusNotFound}
+	ErrLevelTooLowToEnterTournament = &CustomError{Code: "LEVEL_TOO_LOW", Message: "level too low to enter", Status: http.StatusForbidden}
+	ErrInsufficientCoins            = &CustomError{Code: "INSUFFICIENT_COINS", Message: "insufficient coins", Status: http.StatusForbidden}
+	ErrTournamentRegistrationClosed = &CustomError{Code: "TOURNAMENT_REGISTRATION_CLOSED", Message: "tournament registration closed", Status: http.StatusConflict}
+	ErrNoUnclaimedReward            = &CustomError{Code: "NO_UNCLAIMED_REWARD", Message: "no unclaimed reward", Status: http.StatusNotFound}
+	ErrUserNotRanked                = &CustomError{Code: "USER_NOT_RANKED", Message: "user is not ranked on this leaderboard", Status: http.StatusNotFound}
+	ErrInvalidCursor                = &CustomError{Code: "INVALID_CURSOR", Message: "invalid pagination cursor", Status: http.StatusBadRequest}
+	ErrInvalidRefreshToken          = &CustomError{Code: "INVALID_REFRESH_TOKEN", Message: "invalid or expired refresh token", Status: http.StatusUnauthorized}
+	ErrNotInTournament              = &CustomError{Code: "NOT_IN_TOURNAMENT", Message: "user has not entered the active tournament", Status: http.StatusNotFound}
+
+	ErrInvalidRequest        = &CustomError{Code: "INVALID_REQUEST", Message: "invalid request", Status: http.StatusBadRequest}
+	ErrValidationFailed      = &CustomError{Code: "VALIDATION_FAILED", Message: "request validation failed", Status: http.StatusBadRequest}
+	ErrRouteNotFound         = &CustomError{Code: "ROUTE_NOT_FOUND", Message: "route not found", Status: http.StatusNotFound}
+	ErrUnauthorized          = &CustomError{Code: "UNAUTHORIZED", Message: "authentication required", Status: http.StatusUnauthorized}
+	ErrInvalidToken          = &CustomError{Code: "INVALID_TOKEN", Message: "invalid or expired token", Status: http.StatusUnauthorized}
+	ErrTokenRevoked          = &CustomError{Code: "TOKEN_REVOKED", Message: "token has been revoked", Status: http.StatusUnauthorized}
+	ErrTokenCheckUnavailable = &CustomError{Code: "TOKEN_CHECK_UNAVAILABLE", Message: "unable to verify token", Status: http.StatusServiceUnavailable}
+	ErrServiceAuthDisabled   = &CustomError{Code: "SERVICE_AUTH_DISABLED", Message: "service authentication is not enabled", Status: http.StatusUnauthorized}
+	ErrInvalidSignature      = &CustomError{Code: "INVALID_SIGNATURE", Message: "invalid signature", Status: http.StatusUnauthorized}
+	ErrForbidden             = &CustomError{Code: "FORBIDDEN", Message: "forbidden", Status: http.StatusForbidden}
+	ErrWebhookNotConfigured  = &CustomError{Code: "WEBHOOK_NOT_CONFIGURED", Message: "webhook secret is not configured", Status: http.StatusServiceUnavailable}
+	ErrRateLimited           = &CustomError{Code: "RATE_LIMITED", Message: "too many requests", Status: http.StatusTooManyRequests}
+	ErrAccountLocked         = &CustomError{Code: "ACCOUNT_LOCKED", Message: "account temporarily locked after failed logins", Status: http.StatusTooManyRequests}
 )
@@ -0,0 +1,8 @@
+package events
+
+type LeaderboardRankChangedEvent struct {
+	Board  string `json:"board"`
+	UserID int64  `json:"user_id"`
+	Score  int64  `json:"score"`
+	Rank   int64  `json:"rank"`
+}
@@ -3,4 +3,5 @@ package events
 type ProgressUpdateMessage struct {
 	UserID  int64  `json:"user_id"`
 	Country string `json:"country"`
+	Level   int    `json:"level"`
 }
@@ -3,82 +3,103 @@ package consumer
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
-	"github.com/redis/go-redis/v9"
+	"goodblast/internal/application/repository"
+	"goodblast/internal/application/service"
+	"goodblast/internal/domain/entity"
 	"goodblast/internal/domain/events"
 	"goodblast/pkg/log"
-	"strconv"
 )
 
 type LeaderboardConsumer struct {
-	redisClient *redis.Client
-	kafkaConfig *kafka.ConfigMap
+	leaderboardStore        repository.ILeaderboardStore
+	leaderboardEventService service.ILeaderboardEventService
+	kafkaConfig             *kafka.ConfigMap
+	subscription            topicSubscription
 }
 
-func NewLeaderboardConsumer(redisClient *redis.Client, kafkaConfig *kafka.ConfigMap) *LeaderboardConsumer {
+func NewLeaderboardConsumer(
+	leaderboardStore repository.ILeaderboardStore,
+	leaderboardEventService service.ILeaderboardEventService,
+	kafkaConfig *kafka.ConfigMap,
+) *LeaderboardConsumer {
 	return &LeaderboardConsumer{
-		redisClient: redisClient,
-		kafkaConfig: kafkaConfig,
+		leaderboardStore:        leaderboardStore,
+		leaderboardEventService: leaderboardEventService,
+		kafkaConfig:             kafkaConfig,
+		subscription:            newTopicSubscription(),
 	}
 }
 
-func (lc *LeaderboardConsumer) StartLeaderboardUpdateConsumer(topic string) {
+// SetTopic moves the running consumer to topic.
+func (lc *LeaderboardConsumer) SetTopic(topic string) {
+	lc.subscription.set(topic)
+}
+
+// StartLeaderboardUpdateConsumer consumes topic until ctx is cancelled.
+func (lc *LeaderboardConsumer) StartLeaderboardUpdateConsumer(ctx context.Context, topic string) {
 	consumer, err := kafka.NewConsumer(lc.kafkaConfig)
 	if err != nil {
-		log.GetLogger().Error(fmt.Sprintf("Failed to create Kafka consumer: %v", err))
+		log.GetLogger().WithError(err).Error("Failed to create Kafka consumer")
 		return
 	}
+	defer consumer.Close()
 
 	err = consumer.SubscribeTopics([]string{topic}, nil)
 	if err != nil {
-		log.GetLogger().Error(fmt.Sprintf("Failed to subscribe to topic %s: %v", topic, err))
+		log.GetLogger().WithError(err).Errorf("Failed to subscribe to topic %s", topic)
 		return
 	}
 
-	for {
-		msg, err := consumer.ReadMessage(-1)
-		if err == nil {
-			var updateMessage events.LeaderboardUpdateMessage
-			err := json.Unmarshal(msg.Value, &updateMessage)
-			if err != nil {
-				log.GetLogger().Error(fmt.Sprintf("Failed to unmarshal leaderboard update message: %v", err))
-				continue
-			}
+	// A message already read is processed even while shutting down.
+	handleCtx := context.WithoutCancel(ctx)
+	for ctx.Err() == nil {
+		lc.subscription.apply(consumer)
+
+		msg, err := consumer.ReadMessage(readTimeout)
+		if isReadTimeout(err) {
+			continue
+		}
+		if err != nil {
+			readErrors.Inc()
+			log.GetLogger().WithError(err).Error("Consumer error")
+			continue
+		}
+		observeMessage(handleCtx, consumer, msg, func(ctx context.Context) error {
+			return lc.handleMessage(ctx, msg)
+		})
+	}
+}
 
-			ctx := context.Background()
+func (lc *LeaderboardConsumer) handleMessage(ctx context.Context, msg *kafka.Message) error {
+	var updateMessage events.LeaderboardUpdateMessage
+	if err := json.Unmarshal(msg.Value, &updateMessage); err != nil {
+		log.FromContext(ctx).WithError(err).Error("Failed to unmarshal leaderboard update message")
+		return err
+	}
 
-			// **Global LeaderBoard**
-			_, err = lc.redisClient.ZAdd(ctx, "leaderboard:global", []redis.Z{{
-				Score:  float64(updateMessage.Score),
-				Member: strconv.FormatInt(updateMessage.UserID, 10),
-			}}...).Result()
-			if err != nil {
-				log.GetLogger().Error(fmt.Sprintf("Failed to update global leaderboard for user %d: %v", updateMessage.UserID, err))
-			}
+	ctx = log.WithTournamentID(log.WithUserID(ctx, updateMessage.UserID), updateMessage.TournamentID)
 
-			// **Country LeaderBoard**
-			_, err = lc.redisClient.ZAdd(ctx, fmt.Sprintf("leaderboard:%s", updateMessage.Country), []redis.Z{{
-				Score:  float64(updateMessage.Score),
-				Member: strconv.FormatInt(updateMessage.UserID, 10),
-			}}...).Result()
-			if err != nil {
-				log.GetLogger().Error(fmt.Sprintf("Failed to update country leaderboard for user %d in %s: %v", updateMessage.UserID, updateMessage.Country, err))
-			}
+	// Tournament scores reset every day, so they only feed the tournament boards.
+	boards := []entity.Leaderboard{entity.TournamentLeaderboard(updateMessage.TournamentID)}
+	if updateMessage.GroupID != 0 {
+		boards = append(boards, entity.TournamentGroupLeaderboard(updateMessage.TournamentID, updateMessage.GroupID))
+	}
 
-			// **Tournament LeaderBoard**
-			_, err = lc.redisClient.ZAdd(ctx, fmt.Sprintf("leaderboard:tournament:%d", updateMessage.TournamentID), []redis.Z{{
-				Score:  float64(updateMessage.Score),
-				Member: strconv.FormatInt(updateMessage.UserID, 10),
-			}}...).Result()
-			if err != nil {
-				log.GetLogger().Error(fmt.Sprintf("Failed to update tournament leaderboard for user %d in tournament %d: %v", updateMessage.UserID, updateMessage.TournamentID, err))
-			}
+	err := lc.leaderboardStore.SetScore(ctx, updateMessage.UserID, int64(updateMessage.Score), boards...)
+	if err != nil {
+		log.FromContext(ctx).WithError(err).Errorf("Failed to update tournament leaderboard for user %d in tournament %d", updateMessage.UserID, updateMessage.TournamentID)
+		return err
+	}
 
-			log.GetLogger().Info(fmt.Sprintf("Updated leaderboards for user %d - Score: %d, Country: %s, Tournament: %d",
-				updateMessage.UserID, updateMessage.Score, updateMessage.Country, updateMessage.TournamentID))
-		} else {
-			log.GetLogger().Error(fmt.Sprintf("Consumer error: %v", err))
+	if updateMessage.GroupID != 0 {
+		groupBoard := entity.TournamentGroupLeaderboard(updateMessage.TournamentID, updateMessage.GroupID)
+		if err := lc.leaderboardEventService.PublishRankChange(ctx, groupBoard, updateMessage.UserID); err != nil {
+			log.FromContext(ctx).WithError(err).Errorf("Failed to publish group rank change for user %d", updateMessage.UserID)
 		}
 	}
+
+	log.FromContext(ctx).Infof("Updated tournament leaderboard for user %d - Score: %d, Tournament: %d",
+		updateMessage.UserID, updateMessage.Score, updateMessage.TournamentID)
+	return nil
 }
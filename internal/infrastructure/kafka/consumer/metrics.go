@@ -0,0 +1,82 @@
+package consumer
+
+import (
+	"context"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"goodblast/pkg/tracing"
+	"strconv"
+	"time"
+)
+
+var (
+	consumerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "goodblast_kafka_consumer_lag_messages",
+		Help: "Messages behind the partition's high watermark after the last message read.",
+	}, []string{"topic", "partition"})
+
+	messageAge = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "goodblast_kafka_message_age_seconds",
+		Help:    "Time between a message being produced and read, by topic.",
+		Buckets: []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300, 900},
+	}, []string{"topic"})
+
+	handleDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "goodblast_kafka_handle_duration_seconds",
+		Help:    "Time spent handling a message, by topic.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"topic"})
+
+	handleErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "goodblast_kafka_handle_errors_total",
+		Help: "Messages that could not be decoded or handled, by topic.",
+	}, []string{"topic"})
+
+	readErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "goodblast_kafka_read_errors_total",
+		Help: "Errors reported by consumers while reading, other than poll timeouts.",
+	})
+)
+
+// observeMessage runs handle for msg in a span continuing the producer's
+// trace, recording how far behind the consumer is, how long handling took and
+// whether it failed.
+func observeMessage(ctx context.Context, consumer *kafka.Consumer, msg *kafka.Message, handle func(ctx context.Context) error) {
+	topic := *msg.TopicPartition.Topic
+	partition := msg.TopicPartition.Partition
+
+	ctx, span := tracing.Tracer().Start(tracing.ExtractKafkaHeaders(ctx, msg), topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingOperationTypeProcess,
+			semconv.MessagingDestinationName(topic),
+			semconv.MessagingDestinationPartitionID(strconv.Itoa(int(partition))),
+			semconv.MessagingKafkaOffset(int(msg.TopicPartition.Offset)),
+		),
+	)
+	defer span.End()
+
+	if !msg.Timestamp.IsZero() {
+		messageAge.WithLabelValues(topic).Observe(time.Since(msg.Timestamp).Seconds())
+	}
+	// The watermarks are cached from fetch responses, so this does not call
+	// the broker.
+	if _, high, err := consumer.GetWatermarkOffsets(topic, partition); err == nil && high > 0 {
+		lag := high - int64(msg.TopicPartition.Offset) - 1
+		consumerLag.WithLabelValues(topic, strconv.Itoa(int(partition))).Set(float64(max(lag, 0)))
+	}
+
+	start := time.Now()
+	err := handle(ctx)
+	handleDuration.WithLabelValues(topic).Observe(time.Since(start).Seconds())
+	if err != nil {
+		handleErrors.WithLabelValues(topic).Inc()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
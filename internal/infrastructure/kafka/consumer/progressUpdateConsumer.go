@@ -3,7 +3,6 @@ package consumer
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"goodblast/internal/application/service"
 	"goodblast/internal/domain/events"
@@ -11,48 +10,89 @@ import (
 )
 
 type ProgressUpdateConsumer struct {
-	tournamentService service.ITournamentService
-	kafkaConfig       *kafka.ConfigMap
+	tournamentService  service.ITournamentService
+	leaderboardService service.ILeaderboardService
+	kafkaConfig        *kafka.ConfigMap
+	subscription       topicSubscription
 }
 
-func NewProgressUpdateConsumer(tournamentService service.ITournamentService, kafkaConfig *kafka.ConfigMap) *ProgressUpdateConsumer {
+func NewProgressUpdateConsumer(
+	tournamentService service.ITournamentService,
+	leaderboardService service.ILeaderboardService,
+	kafkaConfig *kafka.ConfigMap,
+) *ProgressUpdateConsumer {
 	return &ProgressUpdateConsumer{
-		tournamentService: tournamentService,
-		kafkaConfig:       kafkaConfig,
+		tournamentService:  tournamentService,
+		leaderboardService: leaderboardService,
+		kafkaConfig:        kafkaConfig,
+		subscription:       newTopicSubscription(),
 	}
 }
 
-func (puc *ProgressUpdateConsumer) StartProgressUpdateConsumer(topic string) {
+// SetTopic moves the running consumer to topic.
+func (puc *ProgressUpdateConsumer) SetTopic(topic string) {
+	puc.subscription.set(topic)
+}
+
+// StartProgressUpdateConsumer consumes topic until ctx is cancelled.
+func (puc *ProgressUpdateConsumer) StartProgressUpdateConsumer(ctx context.Context, topic string) {
 	consumer, err := kafka.NewConsumer(puc.kafkaConfig)
 	if err != nil {
-		log.GetLogger().Error(fmt.Sprintf("Failed to create Kafka consumer: %v", err))
+		log.GetLogger().WithError(err).Error("Failed to create Kafka consumer")
 		return
 	}
+	defer consumer.Close()
 
 	err = consumer.SubscribeTopics([]string{topic}, nil)
 	if err != nil {
-		log.GetLogger().Error(fmt.Sprintf("Failed to subscribe to topic %s: %v", topic, err))
+		log.GetLogger().WithError(err).Errorf("Failed to subscribe to topic %s", topic)
 		return
 	}
 
-	for {
-		msg, err := consumer.ReadMessage(-1)
-		if err == nil {
-			var updateMessage events.ProgressUpdateMessage
-			err := json.Unmarshal(msg.Value, &updateMessage)
-			if err != nil {
-				log.GetLogger().Error(fmt.Sprintf("Failed to unmarshal progress update message: %v", err))
-				continue
-			}
-
-			log.GetLogger().Info(fmt.Sprintf("Received progress update for user %d", updateMessage.UserID))
-
-			err = puc.tournamentService.UpdateTournamentScore(context.Background(), updateMessage)
-			if err != nil {
-				log.GetLogger().Error(fmt.Sprintf("Failed to update tournament score for user %d: %v", updateMessage.UserID, err))
-			}
-		} else {
-			log.GetLogger().Error(fmt.Sprintf("Consumer error: %v", err))
+	// A message already read is processed even while shutting down.
+	handleCtx := context.WithoutCancel(ctx)
+	for ctx.Err() == nil {
+		puc.subscription.apply(consumer)
+
+		msg, err := consumer.ReadMessage(readTimeout)
+		if isReadTimeout(err) {
+			continue
+		}
+		if err != nil {
+			readErrors.Inc()
+			log.GetLogger().WithError(err).Error("Consumer error")
+			continue
 		}
+		observeMessage(handleCtx, consumer, msg, func(ctx context.Context) error {
+			return puc.handleMessage(ctx, msg)
+		})
+	}
+}
+
+// handleMessage records the progress on the leaderboards and the tournament.
+// Both are attempted; the first failure is returned.
+func (puc *ProgressUpdateConsumer) handleMessage(ctx context.Context, msg *kafka.Message) error {
+	var updateMessage events.ProgressUpdateMessage
+	if err := json.Unmarshal(msg.Value, &updateMessage); err != nil {
+		log.FromContext(ctx).WithError(err).Error("Failed to unmarshal progress update message")
+		return err
+	}
+
+	ctx = log.WithUserID(ctx, updateMessage.UserID)
+	log.FromContext(ctx).Infof("Received progress update for user %d", updateMessage.UserID)
+
+	leaderboardErr := puc.leaderboardService.RecordProgress(ctx, updateMessage.UserID, updateMessage.Country, updateMessage.Level)
+	if leaderboardErr != nil {
+		log.FromContext(ctx).WithError(leaderboardErr).Errorf("Failed to record leaderboard progress for user %d", updateMessage.UserID)
+	}
+
+	tournamentErr := puc.tournamentService.UpdateTournamentScore(ctx, updateMessage)
+	if tournamentErr != nil {
+		log.FromContext(ctx).WithError(tournamentErr).Errorf("Failed to update tournament score for user %d", updateMessage.UserID)
+	}
+
+	if leaderboardErr != nil {
+		return leaderboardErr
 	}
+	return tournamentErr
 }
@@ -0,0 +1,54 @@
+package consumer
+
+import (
+	"errors"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"goodblast/pkg/log"
+	"time"
+)
+
+// readTimeout bounds each poll so a consumer loop notices topic changes
+// without waiting for the next message.
+const readTimeout = time.Second
+
+// topicSubscription hands a new topic to a consumer loop, which resubscribes
+// between reads. Only the latest topic is kept when several arrive at once.
+type topicSubscription struct {
+	pending chan string
+}
+
+func newTopicSubscription() topicSubscription {
+	return topicSubscription{pending: make(chan string, 1)}
+}
+
+func (s topicSubscription) set(topic string) {
+	for {
+		select {
+		case s.pending <- topic:
+			return
+		default:
+		}
+		select {
+		case <-s.pending:
+		default:
+		}
+	}
+}
+
+// apply resubscribes consumer if a new topic was set since the last call.
+func (s topicSubscription) apply(consumer *kafka.Consumer) {
+	select {
+	case topic := <-s.pending:
+		if err := consumer.SubscribeTopics([]string{topic}, nil); err != nil {
+			log.GetLogger().WithError(err).Errorf("Failed to resubscribe to topic %s", topic)
+			return
+		}
+		log.GetLogger().Infof("Resubscribed Kafka consumer to topic %s", topic)
+	default:
+	}
+}
+
+func isReadTimeout(err error) bool {
+	var kafkaErr kafka.Error
+	return errors.As(err, &kafkaErr) && kafkaErr.IsTimeout()
+}
@@ -12,56 +12,73 @@ import (
 
 type TournamentEntryConsumer struct {
 	consumer          *ckafka.Consumer
-	topic             string
 	tournamentService service.ITournamentService
+	subscription      topicSubscription
 }
 
 func NewTournamentEntryConsumer(
 	consumer *ckafka.Consumer,
-	topic string,
 	tournamentService service.ITournamentService,
 ) *TournamentEntryConsumer {
 	return &TournamentEntryConsumer{
 		consumer:          consumer,
-		topic:             topic,
 		tournamentService: tournamentService,
+		subscription:      newTopicSubscription(),
 	}
 }
 
-func (tc *TournamentEntryConsumer) StartConsume(ctx context.Context) error {
-	err := tc.consumer.SubscribeTopics([]string{tc.topic}, nil)
+// SetTopic moves the running consumer to topic.
+func (tc *TournamentEntryConsumer) SetTopic(topic string) {
+	tc.subscription.set(topic)
+}
+
+// StartConsume subscribes to topic and consumes until ctx is cancelled and the
+// message being handled is done, then closes the consumer.
+func (tc *TournamentEntryConsumer) StartConsume(ctx context.Context, topic string) error {
+	defer tc.consumer.Close()
+
+	err := tc.consumer.SubscribeTopics([]string{topic}, nil)
 	if err != nil {
-		return fmt.Errorf("failed to subscribe to topic %s: %w", tc.topic, err)
+		return fmt.Errorf("failed to subscribe to topic %s: %w", topic, err)
 	}
 
-	go func() {
-		for {
-			msg, err := tc.consumer.ReadMessage(-1)
-			if err != nil {
-				log.GetLogger().Errorf(fmt.Sprintf("Kafka consumer read error: %v", err))
-				continue
-			}
-			tc.handleMessage(ctx, msg)
+	// A message already read is processed even while shutting down.
+	handleCtx := context.WithoutCancel(ctx)
+	for ctx.Err() == nil {
+		tc.subscription.apply(tc.consumer)
+
+		msg, err := tc.consumer.ReadMessage(readTimeout)
+		if isReadTimeout(err) {
+			continue
 		}
-	}()
+		if err != nil {
+			readErrors.Inc()
+			log.GetLogger().WithError(err).Error("Kafka consumer read error")
+			continue
+		}
+		observeMessage(handleCtx, tc.consumer, msg, func(ctx context.Context) error {
+			return tc.handleMessage(ctx, msg)
+		})
+	}
 	return nil
 }
 
-func (tc *TournamentEntryConsumer) handleMessage(ctx context.Context, msg *ckafka.Message) {
+func (tc *TournamentEntryConsumer) handleMessage(ctx context.Context, msg *ckafka.Message) error {
 	var payload events.EnterTournamentPayload
 	if err := json.Unmarshal(msg.Value, &payload); err != nil {
-		log.GetLogger().Errorf(fmt.Sprintf("Failed to unmarshal tournament entry payload: %v", err))
-		return
+		log.FromContext(ctx).WithError(err).Error("Failed to unmarshal tournament entry payload")
+		return err
 	}
+	ctx = log.WithUserID(ctx, payload.UserID)
 
-	log.GetLogger().Infof(fmt.Sprintf("Received a tournament entry message for userID=%d from topic=%s",
-		payload.UserID, *msg.TopicPartition.Topic))
+	log.FromContext(ctx).Infof("Received a tournament entry message for userID=%d from topic=%s", payload.UserID, *msg.TopicPartition.Topic)
 
 	err := tc.tournamentService.EnterTournament(ctx, payload.UserID)
 	if err != nil {
-		log.GetLogger().Errorf(fmt.Sprintf("EnterTournamentTransaction failed for userID=%d, err=%v", payload.UserID, err))
-		return
+		log.FromContext(ctx).WithError(err).Errorf("EnterTournamentTransaction failed for userID=%d", payload.UserID)
+		return err
 	}
 
-	log.GetLogger().Infof(fmt.Sprintf("Successfully processed tournament entry for userID=%d", payload.UserID))
+	log.FromContext(ctx).Infof("Successfully processed tournament entry for userID=%d", payload.UserID)
+	return nil
 }
@@ -1,49 +1,85 @@
 package producer
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	ckafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
-	"sync"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"goodblast/pkg/tracing"
+	"time"
 )
 
 type Producer struct {
 	producer *ckafka.Producer
 }
 
-var (
-	instance *Producer
-	once     sync.Once
-)
-
 func NewKafkaProducer(configMap *ckafka.ConfigMap) (*Producer, error) {
-	var err error
-	once.Do(func() {
-		p, e := ckafka.NewProducer(configMap)
-		if e != nil {
-			err = fmt.Errorf("failed to create kafka producer: %w", e)
-			return
-		}
-		instance = &Producer{producer: p}
-	})
+	p, err := ckafka.NewProducer(configMap)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to create kafka producer: %w", err)
 	}
-	return instance, nil
+	return &Producer{producer: p}, nil
 }
 
 func (k *Producer) GetProducer() *ckafka.Producer {
 	return k.producer
 }
 
-func ProduceFireAndForget(producer *ckafka.Producer, topic string, data []byte) error {
-	err := producer.Produce(&ckafka.Message{
+// Close waits up to timeout for queued messages to be delivered and then
+// closes the producer. It returns the number of messages left undelivered.
+func (k *Producer) Close(timeout time.Duration) int {
+	remaining := k.producer.Flush(int(timeout / time.Millisecond))
+	k.producer.Close()
+	return remaining
+}
+
+// CheckBrokers fetches the cluster metadata, failing when no broker answers
+// before ctx is done.
+func CheckBrokers(ctx context.Context, producer *ckafka.Producer) error {
+	timeout := 5 * time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+
+	metadata, err := producer.GetMetadata(nil, false, int(timeout/time.Millisecond))
+	if err != nil {
+		return fmt.Errorf("failed to fetch kafka metadata: %w", err)
+	}
+	if len(metadata.Brokers) == 0 {
+		return errors.New("no kafka brokers available")
+	}
+	return nil
+}
+
+// ProduceFireAndForget enqueues data without waiting for delivery. The
+// message headers carry the trace of ctx so consumers can continue it.
+func ProduceFireAndForget(ctx context.Context, producer *ckafka.Producer, topic string, data []byte) error {
+	ctx, span := tracing.Tracer().Start(ctx, topic+" send",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingOperationTypeSend,
+			semconv.MessagingDestinationName(topic),
+		),
+	)
+	defer span.End()
+
+	msg := &ckafka.Message{
 		TopicPartition: ckafka.TopicPartition{
 			Topic:     &topic,
 			Partition: ckafka.PartitionAny,
 		},
 		Value: data,
-	}, nil)
+	}
+	tracing.InjectKafkaHeaders(ctx, msg)
+
+	err := producer.Produce(msg, nil)
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		return fmt.Errorf("failed to enqueue message: %w", err)
 	}
 
@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/uptrace/bun"
+	"strings"
+	"time"
+)
+
+var (
+	queryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "goodblast_db_query_duration_seconds",
+		Help:    "Postgres query latency by operation.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation"})
+
+	queryErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "goodblast_db_query_errors_total",
+		Help: "Failed Postgres queries by operation. Queries returning no rows are not errors.",
+	}, []string{"operation"})
+)
+
+// metricsQueryHook observes every query run through bun.
+type metricsQueryHook struct{}
+
+func NewMetricsQueryHook() bun.QueryHook {
+	return metricsQueryHook{}
+}
+
+func (metricsQueryHook) BeforeQuery(ctx context.Context, _ *bun.QueryEvent) context.Context {
+	return ctx
+}
+
+func (metricsQueryHook) AfterQuery(_ context.Context, event *bun.QueryEvent) {
+	operation := strings.ToUpper(event.Operation())
+	queryDuration.WithLabelValues(operation).Observe(time.Since(event.StartTime).Seconds())
+	if event.Err != nil && !errors.Is(event.Err, sql.ErrNoRows) {
+		queryErrors.WithLabelValues(operation).Inc()
+	}
+}
@@ -69,6 +69,8 @@ func (db *goodBlastDatabase) Connect(config ConnectionConfig) *bun.DB {
 
 	dbInstance := bun.NewDB(sqlDB, pgdialect.New())
 	dbInstance.AddQueryHook(debugHook)
+	dbInstance.AddQueryHook(NewMetricsQueryHook())
+	dbInstance.AddQueryHook(NewTracingQueryHook())
 	dbInstance.SetConnMaxLifetime(5 * time.Minute)
 	dbInstance.SetMaxIdleConns(10)
 	dbInstance.SetMaxOpenConns(30)
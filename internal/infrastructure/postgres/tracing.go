@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/uptrace/bun"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"goodblast/pkg/tracing"
+	"strings"
+)
+
+// tracingQueryHook wraps every query run through bun in a client span. The
+// query text is left out because bun inlines the arguments, which include
+// password hashes and tokens.
+type tracingQueryHook struct{}
+
+func NewTracingQueryHook() bun.QueryHook {
+	return tracingQueryHook{}
+}
+
+func (tracingQueryHook) BeforeQuery(ctx context.Context, event *bun.QueryEvent) context.Context {
+	operation := strings.ToUpper(event.Operation())
+	attributes := []attribute.KeyValue{
+		semconv.DBSystemNamePostgreSQL,
+		semconv.DBOperationName(operation),
+	}
+	if query, ok := event.IQuery.(interface{ GetTableName() string }); ok && query.GetTableName() != "" {
+		attributes = append(attributes, semconv.DBCollectionName(query.GetTableName()))
+	}
+
+	ctx, _ = tracing.Tracer().Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributes...),
+	)
+	return ctx
+}
+
+func (tracingQueryHook) AfterQuery(ctx context.Context, event *bun.QueryEvent) {
+	span := trace.SpanFromContext(ctx)
+	if event.Err != nil && !errors.Is(event.Err, sql.ErrNoRows) {
+		span.RecordError(event.Err)
+		span.SetStatus(codes.Error, event.Err.Error())
+	}
+	span.End()
+}
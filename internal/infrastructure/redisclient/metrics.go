@@ -0,0 +1,60 @@
+package redisclient
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// pipelineCommand labels pipelines and transactions, which are observed as a
+// whole.
+const pipelineCommand = "pipeline"
+
+var (
+	commandDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "goodblast_redis_command_duration_seconds",
+		Help:    "Redis command latency by command.",
+		Buckets: []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25},
+	}, []string{"command"})
+
+	commandErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "goodblast_redis_command_errors_total",
+		Help: "Failed Redis commands by command. Missing keys are not errors.",
+	}, []string{"command"})
+)
+
+// metricsHook observes every command sent through the client. Blocking
+// commands like SUBSCRIBE are observed until they return.
+type metricsHook struct{}
+
+func (metricsHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (metricsHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		observeCommand(cmd.Name(), start, err)
+		return err
+	}
+}
+
+func (metricsHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		observeCommand(pipelineCommand, start, err)
+		return err
+	}
+}
+
+func observeCommand(command string, start time.Time, err error) {
+	commandDuration.WithLabelValues(command).Observe(time.Since(start).Seconds())
+	if err != nil && !errors.Is(err, redis.Nil) {
+		commandErrors.WithLabelValues(command).Inc()
+	}
+}
@@ -29,6 +29,8 @@ func NewClient(config *appconfig.Config) *Client {
 		panic(fmt.Sprintf("Failed to connect to Redis: %v", err))
 	}
 
+	rdb.AddHook(metricsHook{})
+	rdb.AddHook(tracingHook{})
 	log.GetLogger().Info("Connected to Redis successfully")
 
 	return &Client{Client: rdb}
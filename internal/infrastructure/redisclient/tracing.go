@@ -0,0 +1,64 @@
+package redisclient
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"goodblast/pkg/tracing"
+	"strings"
+)
+
+// tracingHook wraps every command in a client span named after it, and
+// pipelines in one span listing their commands. Arguments are left out since
+// they carry user data.
+type tracingHook struct{}
+
+func (tracingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (tracingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := startSpan(ctx, strings.ToUpper(cmd.Name()))
+		defer span.End()
+
+		err := next(ctx, cmd)
+		recordSpanError(span, err)
+		return err
+	}
+}
+
+func (tracingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		names := make([]string, len(cmds))
+		for i, cmd := range cmds {
+			names[i] = strings.ToUpper(cmd.Name())
+		}
+
+		ctx, span := startSpan(ctx, strings.ToUpper(pipelineCommand),
+			attribute.StringSlice("db.redis.commands", names))
+		defer span.End()
+
+		err := next(ctx, cmds)
+		recordSpanError(span, err)
+		return err
+	}
+}
+
+func startSpan(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(append(attributes, semconv.DBSystemNameRedis, semconv.DBOperationName(name))...),
+	)
+}
+
+func recordSpanError(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, redis.Nil) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
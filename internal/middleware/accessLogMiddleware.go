@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"goodblast/pkg/log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// accessLogSkippedPrefixes are polled by orchestrators and scrapers and would
+// drown out the requests worth reading.
+var accessLogSkippedPrefixes = []string{"/healthcheck", "/_monitoring"}
+
+// AccessLogMiddleware logs one line per request once it has been handled,
+// with the fields attached to the request context. Server errors are logged
+// at error level and client errors at warn level.
+func AccessLogMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		for _, prefix := range accessLogSkippedPrefixes {
+			if strings.HasPrefix(ctx.Request.URL.Path, prefix) {
+				ctx.Next()
+				return
+			}
+		}
+
+		start := time.Now()
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		entry := log.FromContext(ctx.Request.Context()).WithFields(logrus.Fields{
+			"method":     ctx.Request.Method,
+			"path":       ctx.Request.URL.Path,
+			"status":     status,
+			"latency_ms": time.Since(start).Milliseconds(),
+			"client_ip":  ctx.ClientIP(),
+			"bytes":      max(ctx.Writer.Size(), 0),
+			"user_agent": ctx.Request.UserAgent(),
+		})
+		message := fmt.Sprintf("%s %s %d", ctx.Request.Method, ctx.Request.URL.Path, status)
+
+		switch {
+		case status >= http.StatusInternalServerError:
+			entry.Error(message)
+		case status >= http.StatusBadRequest:
+			entry.Warn(message)
+		default:
+			entry.Info(message)
+		}
+	}
+}
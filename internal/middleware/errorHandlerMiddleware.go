@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"goodblast/internal/application/controller/response"
+	domainErrors "goodblast/internal/domain/errors"
+	"goodblast/internal/validation"
+	"goodblast/pkg/constants"
+	"goodblast/pkg/log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const problemTypePrefix = "urn:goodblast:problem:"
+
+// ErrorHandlerMiddleware writes the last error a handler attached with
+// ctx.Error, and recovered panics, as a problem+json response. Validation
+// errors list the offending fields; errors that are not CustomErrors are
+// logged and reported as internal errors without their message.
+func ErrorHandlerMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				log.FromContext(c.Request.Context()).Errorf("Recovered from panic: %v", rec)
+				c.Abort()
+				if !c.Writer.Written() {
+					writeProblem(c, domainErrors.ErrInternalServerError)
+				}
+			}
+		}()
+
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+		var validationErr *validation.ValidationError
+		if errors.As(err, &validationErr) {
+			writeProblem(c, domainErrors.ErrValidationFailed, validationErr.Fields...)
+			return
+		}
+		var retryableErr *domainErrors.RetryableError
+		if errors.As(err, &retryableErr) {
+			c.Header("Retry-After", strconv.Itoa(retryAfterSeconds(retryableErr.RetryAfter)))
+		}
+		writeProblem(c, mapError(c, err))
+	}
+}
+
+// NoRouteHandler reports unknown routes in the same shape as other errors.
+func NoRouteHandler(c *gin.Context) {
+	c.Error(domainErrors.ErrRouteNotFound.WithDetail(c.Request.Method + " " + c.Request.URL.Path))
+}
+
+func mapError(c *gin.Context, err error) *domainErrors.CustomError {
+	var customErr *domainErrors.CustomError
+	if errors.As(err, &customErr) {
+		if customErr.Status >= 500 {
+			log.FromContext(c.Request.Context()).WithError(err).Error("Request failed")
+		}
+		return customErr
+	}
+
+	log.FromContext(c.Request.Context()).WithError(err).Error("Request failed with an unexpected error")
+	return domainErrors.ErrInternalServerError
+}
+
+// retryAfterSeconds rounds up, so a client waiting the advertised time is not
+// rejected again.
+func retryAfterSeconds(retryAfter time.Duration) int {
+	return max(int(math.Ceil(retryAfter.Seconds())), 1)
+}
+
+func writeProblem(c *gin.Context, customErr *domainErrors.CustomError, fieldErrs ...validation.FieldError) {
+	problem := response.ProblemResponse{
+		Type:          problemTypePrefix + strings.ToLower(strings.ReplaceAll(customErr.Code, "_", "-")),
+		Title:         customErr.Message,
+		Status:        customErr.Status,
+		Detail:        customErr.Detail,
+		Instance:      c.Request.URL.Path,
+		Code:          customErr.Code,
+		CorrelationID: c.GetString(constants.CorrelationIdKey),
+		Errors:        fieldErrs,
+	}
+
+	c.Header("Content-Type", response.ProblemContentType)
+	c.JSON(customErr.Status, problem)
+}
@@ -1,16 +1,19 @@
 package middleware
 
 import (
+	"bytes"
 	"errors"
-	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/uptrace/bun"
+	"goodblast/internal/domain/entity"
 	domainErrors "goodblast/internal/domain/errors"
 	"goodblast/pkg/auth"
 	"goodblast/pkg/constants"
+	"goodblast/pkg/health"
 	"goodblast/pkg/log"
+	"io"
 	"net/http"
+	"strconv"
 )
 
 func HealthCheckMiddleware(engine *gin.Engine) {
@@ -19,12 +22,25 @@ func HealthCheckMiddleware(engine *gin.Engine) {
 	})
 }
 
+// CorrelationIdMiddleware reuses the caller's correlation ID, or generates
+// one, and echoes it in the response. The ID, the matched route and the
+// tournament in the path are attached to the request context for logging.
 func CorrelationIdMiddleware(context *gin.Context) {
 	correlationId := context.Request.Header.Get(constants.CorrelationIdKey)
 	if correlationId == "" {
 		correlationId = uuid.New().String()
 	}
 	context.Set(constants.CorrelationIdKey, correlationId)
+	context.Header(constants.CorrelationIdKey, correlationId)
+
+	requestCtx := log.WithCorrelationID(context.Request.Context(), correlationId)
+	if route := context.FullPath(); route != "" {
+		requestCtx = log.WithRoute(requestCtx, route)
+	}
+	if tournamentID, err := strconv.ParseInt(context.Param("tournamentId"), 10, 64); err == nil {
+		requestCtx = log.WithTournamentID(requestCtx, tournamentID)
+	}
+	context.Request = context.Request.WithContext(requestCtx)
 	context.Next()
 }
 
@@ -34,30 +50,39 @@ func LivenessHealthCheckMiddleware(engine *gin.Engine) {
 	})
 }
 
-func ReadinessHealthCheckMiddleware(engine *gin.Engine, pgClient *bun.DB) {
+// ReadinessHealthCheckMiddleware serves the report of the registered checks.
+// The instance is ready unless a critical check fails; a failing non-critical
+// check is reported as degraded but keeps it in rotation.
+func ReadinessHealthCheckMiddleware(engine *gin.Engine, registry *health.Registry) {
 	engine.GET("/healthcheck/readiness", func(ctx *gin.Context) {
-		if pgClient == nil {
-			fmt.Println("Service unavailable for readiness healthcheck and pgClient=null")
-			ctx.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable"})
-			return
+		report := registry.Run(ctx.Request.Context())
+		for _, check := range report.Checks {
+			if check.Status != health.StatusHealthy {
+				log.FromContext(ctx.Request.Context()).Warnf("Readiness check %s failed: %s", check.Name, check.Error)
+			}
 		}
 
-		err := pgClient.Ping()
-		if err != nil {
-			fmt.Println("Service unavailable for liveness healthcheck and could not ping to pgClient", err.Error())
-			ctx.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "error": err.Error()})
+		if report.Status == health.StatusUnavailable {
+			ctx.JSON(http.StatusServiceUnavailable, report)
 			return
 		}
-		ctx.JSON(http.StatusOK, gin.H{"status": "healthy"})
-
+		ctx.JSON(http.StatusOK, report)
 	})
 }
 
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware authenticates either a backend service, by the HMAC
+// signature headers, or a user, by an access token that has not been revoked
+// through the denylist. It fails closed when the denylist cannot be checked.
+func AuthMiddleware(tokenAuth auth.IAuth, denylist auth.ITokenDenylist, serviceAuth *auth.ServiceAuthenticator) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
+		if serviceID := ctx.GetHeader(auth.ServiceIDHeader); serviceID != "" {
+			authenticateService(ctx, serviceAuth, serviceID)
+			return
+		}
+
 		token := ctx.GetHeader("Authorization")
 		if token == "" {
-			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization token is required"})
+			ctx.Error(domainErrors.ErrUnauthorized.WithDetail("authorization token is required"))
 			ctx.Abort()
 			return
 		}
@@ -66,42 +91,87 @@ func AuthMiddleware() gin.HandlerFunc {
 			token = token[7:]
 		}
 
-		payload, err := auth.GetAuth().VerifyToken(token)
+		payload, err := tokenAuth.VerifyToken(token)
 		if err != nil {
-			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			ctx.Error(domainErrors.ErrInvalidToken)
+			ctx.Abort()
+			return
+		}
+
+		revoked, err := denylist.IsRevoked(ctx.Request.Context(), payload)
+		if err != nil {
+			log.FromContext(ctx.Request.Context()).WithError(err).Errorf("Failed to check token denylist for user %d", payload.UserID)
+			ctx.Error(domainErrors.ErrTokenCheckUnavailable)
+			ctx.Abort()
+			return
+		}
+		if revoked {
+			ctx.Error(domainErrors.ErrTokenRevoked)
 			ctx.Abort()
 			return
 		}
 
 		ctx.Set("userID", payload.UserID)
+		ctx.Request = ctx.Request.WithContext(log.WithUserID(ctx.Request.Context(), payload.UserID))
+		ctx.Set(constants.RoleKey, payload.UserRole())
+		ctx.Set(constants.TokenPayloadKey, payload)
 		ctx.Next()
 	}
 }
 
-func ErrorHandlerMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		defer func() {
-			if rec := recover(); rec != nil {
-				log.GetLogger().Errorf("Recovered from panic: %v", rec)
-				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-					"status":  http.StatusInternalServerError,
-					"message": "Internal server error",
-				})
-			}
-		}()
+func authenticateService(ctx *gin.Context, serviceAuth *auth.ServiceAuthenticator, serviceID string) {
+	if serviceAuth == nil {
+		ctx.Error(domainErrors.ErrServiceAuthDisabled)
+		ctx.Abort()
+		return
+	}
 
-		c.Next()
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		ctx.Error(domainErrors.ErrInvalidRequest.WithDetail("unable to read request body"))
+		ctx.Abort()
+		return
+	}
+	ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
 
-		if len(c.Errors) > 0 {
-			err := c.Errors.Last()
-			mapErrorToResponse(c, err.Err)
-		}
+	err = serviceAuth.Verify(ctx.Request.Context(), serviceID, ctx.Request.Method, ctx.Request.URL.RequestURI(),
+		ctx.GetHeader(auth.ServiceTimestampHeader), body, ctx.GetHeader(auth.ServiceSignatureHeader))
+	switch {
+	case errors.Is(err, auth.ErrInvalidServiceSignature):
+		log.FromContext(ctx.Request.Context()).Warnf("Rejected service request from %q to %s", serviceID, ctx.Request.URL.Path)
+		ctx.Error(domainErrors.ErrInvalidSignature)
+		ctx.Abort()
+		return
+	case errors.Is(err, auth.ErrServiceRequestReplayed):
+		log.FromContext(ctx.Request.Context()).Warnf("Rejected replayed service request from %q to %s", serviceID, ctx.Request.URL.Path)
+		ctx.Error(domainErrors.ErrInvalidSignature.WithDetail("the signed request was already used"))
+		ctx.Abort()
+		return
+	case err != nil:
+		log.FromContext(ctx.Request.Context()).WithError(err).Errorf("Failed to check service request from %q for replays", serviceID)
+		ctx.Error(domainErrors.ErrTokenCheckUnavailable)
+		ctx.Abort()
+		return
 	}
+
+	ctx.Set(constants.RoleKey, entity.UserRoleService)
+	ctx.Set(constants.ServiceIDKey, serviceID)
+	ctx.Next()
 }
 
-func mapErrorToResponse(c *gin.Context, err error) {
-	var customErr *domainErrors.CustomError
-	if errors.As(err, &customErr) {
-		c.JSON(customErr.Status, gin.H{"status": customErr.Status, "message": customErr.Message})
+// RequireRole lets the request through only when AuthMiddleware authenticated
+// a principal with one of the given roles.
+func RequireRole(roles ...entity.UserRole) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		role, _ := ctx.Get(constants.RoleKey)
+		for _, allowed := range roles {
+			if role == allowed {
+				ctx.Next()
+				return
+			}
+		}
+
+		ctx.Error(domainErrors.ErrForbidden)
+		ctx.Abort()
 	}
 }
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	appconfig "goodblast/config"
+	domainErrors "goodblast/internal/domain/errors"
+	"goodblast/pkg/log"
+	"goodblast/pkg/ratelimit"
+	"sort"
+	"strconv"
+)
+
+var rateLimitedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "goodblast_http_rate_limited_total",
+	Help: "Requests rejected by a rate limit policy.",
+}, []string{"policy"})
+
+// RateLimitMiddleware applies the rateLimits policies of the dynamic config
+// that match the route and method. Policies keyed by user need the user ID,
+// so the middleware goes after AuthMiddleware on authenticated routes. When
+// Redis cannot be reached requests are let through rather than failing the
+// API.
+func RateLimitMiddleware(limiter ratelimit.ILimiter, dynamicConfigService appconfig.IDynamicConfigService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		policies := dynamicConfigService.GetConfig().RateLimits
+		route := ctx.FullPath()
+
+		// Sorted so the policy reported first does not change between requests.
+		names := make([]string, 0, len(policies))
+		for name, policy := range policies {
+			if policy.Matches(route, ctx.Request.Method) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		limits := make([]ratelimit.Limit, 0, len(names))
+		for _, name := range names {
+			policy := policies[name]
+			limits = append(limits, ratelimit.Limit{
+				Key:    fmt.Sprintf("%s:%s", name, rateLimitSubject(ctx, policy)),
+				Limit:  policy.Limit,
+				Window: policy.Window(),
+			})
+		}
+
+		result, err := limiter.Allow(ctx.Request.Context(), limits...)
+		if err != nil {
+			log.FromContext(ctx.Request.Context()).WithError(err).Warnf("Skipping rate limit policies %v", names)
+		} else if !result.Allowed {
+			name := names[result.Rejected]
+			policy := policies[name]
+			rateLimitedRequests.WithLabelValues(name).Inc()
+			log.FromContext(ctx.Request.Context()).Warnf("Rate limited %s by policy %s", ctx.ClientIP(), name)
+			ctx.Header("X-RateLimit-Limit", strconv.Itoa(policy.Limit))
+			ctx.Error(domainErrors.NewRetryableError(domainErrors.ErrRateLimited.WithDetail(
+				fmt.Sprintf("at most %d requests per %s", policy.Limit, policy.Window())), result.RetryAfter))
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// rateLimitSubject is what the policy counts requests per.
+func rateLimitSubject(ctx *gin.Context, policy appconfig.RateLimitPolicy) string {
+	switch policy.Key {
+	case appconfig.RateLimitKeyRoute:
+		return "route"
+	case appconfig.RateLimitKeyUser:
+		if userID, ok := ctx.Get("userID"); ok {
+			return fmt.Sprintf("user:%d", userID)
+		}
+	}
+	return "ip:" + ctx.ClientIP()
+}
@@ -0,0 +1,91 @@
+package validation
+
+import (
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"reflect"
+	"strings"
+)
+
+// FieldError describes one field that broke a validation rule.
+type FieldError struct {
+	Field   string `json:"field" example:"country"`
+	Rule    string `json:"rule" example:"country"`
+	Message string `json:"message" example:"must be an ISO 3166-1 alpha-2 country code"`
+}
+
+// ValidationError lists every field of a request that broke a rule.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Field + " " + field.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+func newValidationError(validationErrs validator.ValidationErrors) *ValidationError {
+	fields := make([]FieldError, len(validationErrs))
+	for i, fieldErr := range validationErrs {
+		fields[i] = FieldError{
+			Field:   fieldPath(fieldErr),
+			Rule:    fieldErr.Tag(),
+			Message: describe(fieldErr),
+		}
+	}
+	return &ValidationError{Fields: fields}
+}
+
+// fieldPath drops the request struct name from the namespace, so nested
+// fields read like "period" or "items[0].id". Embedded structs have no tag
+// and keep their Go name, so segments named like their Go field are dropped
+// too; every request field carries a json or form tag.
+func fieldPath(fieldErr validator.FieldError) string {
+	names := strings.Split(fieldErr.Namespace(), ".")
+	goNames := strings.Split(fieldErr.StructNamespace(), ".")
+
+	path := make([]string, 0, len(names))
+	for i := 1; i < len(names)-1; i++ {
+		if names[i] != goNames[i] {
+			path = append(path, names[i])
+		}
+	}
+	path = append(path, names[len(names)-1])
+	return strings.Join(path, ".")
+}
+
+func describe(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		if isText(fieldErr) {
+			return fmt.Sprintf("must be at least %s characters", fieldErr.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fieldErr.Param())
+	case "max":
+		if isText(fieldErr) {
+			return fmt.Sprintf("must be at most %s characters", fieldErr.Param())
+		}
+		return fmt.Sprintf("must be at most %s", fieldErr.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.ReplaceAll(fieldErr.Param(), " ", ", "))
+	case RuleCountry:
+		return "must be an ISO 3166-1 alpha-2 country code"
+	case RuleUsername:
+		return fmt.Sprintf("must be %d-%d letters, digits, '_', '.' or '-', starting with a letter or digit",
+			usernameMinLength, usernameMaxLength)
+	case RulePassword:
+		return fmt.Sprintf("must be %d-%d characters with at least one letter and one digit",
+			passwordMinLength, passwordMaxLength)
+	default:
+		return fmt.Sprintf("failed the %s rule", fieldErr.Tag())
+	}
+}
+
+func isText(fieldErr validator.FieldError) bool {
+	return fieldErr.Kind() == reflect.String
+}
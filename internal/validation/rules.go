@@ -0,0 +1,59 @@
+package validation
+
+import (
+	"github.com/go-playground/validator/v10"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	RuleCountry  = "country"
+	RuleUsername = "username"
+	RulePassword = "password"
+
+	usernameMinLength = 3
+	usernameMaxLength = 32
+	passwordMinLength = 8
+	// passwordMaxLength is the most bcrypt hashes; longer passwords are rejected by it.
+	passwordMaxLength = 72
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+func registerRules(validate *validator.Validate) {
+	_ = validate.RegisterValidation(RuleCountry, func(fl validator.FieldLevel) bool {
+		return validate.Var(strings.ToUpper(fl.Field().String()), "iso3166_1_alpha2") == nil
+	})
+	_ = validate.RegisterValidation(RuleUsername, validUsername)
+	_ = validate.RegisterValidation(RulePassword, validPassword)
+}
+
+// validUsername accepts letters, digits, '_', '.' and '-', starting with a
+// letter or digit.
+func validUsername(fl validator.FieldLevel) bool {
+	username := fl.Field().String()
+	if len(username) < usernameMinLength || len(username) > usernameMaxLength {
+		return false
+	}
+	return usernamePattern.MatchString(username)
+}
+
+// validPassword requires at least one letter and one digit.
+func validPassword(fl validator.FieldLevel) bool {
+	password := fl.Field().String()
+	if len(password) < passwordMinLength || len(password) > passwordMaxLength {
+		return false
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	return hasLetter && hasDigit
+}
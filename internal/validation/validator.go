@@ -1,6 +1,11 @@
 package validation
 
-import "github.com/go-playground/validator/v10"
+import (
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"reflect"
+	"strings"
+)
 
 type Validator interface {
 	Validate(interface{}) error
@@ -10,12 +15,44 @@ type RequestValidator struct {
 	validator *validator.Validate
 }
 
+// NewRequestValidator validates the `validate` tags of request structs with
+// the built-in rules and the custom country, username and password rules.
+// Fields are reported by their JSON or query parameter names.
 func NewRequestValidator() *RequestValidator {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(fieldName)
+	registerRules(validate)
+
 	return &RequestValidator{
-		validator: validator.New(),
+		validator: validate,
 	}
 }
 
+// Validate returns a *ValidationError listing every field that breaks a rule.
 func (v *RequestValidator) Validate(obj interface{}) error {
-	return v.validator.Struct(obj)
+	err := v.validator.Struct(obj)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+	return newValidationError(validationErrs)
+}
+
+// fieldName names a field after its json tag, or its form tag for query
+// parameters, falling back to the Go field name.
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name := strings.Split(field.Tag.Get(tag), ",")[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
 }
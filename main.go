@@ -7,6 +7,10 @@ import (
 // @securityDefinitions.apikey BearerAuth
 // @in header
 // @name Authorization
+// @securityDefinitions.apikey ServiceSignature
+// @in header
+// @name X-Service-Signature
+// @description HMAC-SHA256 request signature, sent with X-Service-Id and X-Service-Timestamp
 func main() {
 	cmd.Execute()
 }
@@ -1,61 +1,112 @@
 package auth
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"github.com/google/uuid"
 	"github.com/o1egl/paseto"
 	"goodblast/internal/domain/entity"
-	"sync"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
-var (
-	instance *Auth
-	once     sync.Once
-)
+const v2LocalHeader = "v2.local."
 
+// IAuth issues and verifies access tokens.
 type IAuth interface {
 	GenerateToken(user *entity.User) (string, error)
-	VerifyToken(token string) (map[string]interface{}, error)
+	VerifyToken(token string) (TokenPayload, error)
+	TokenTTL() time.Duration
+	SetTokenTTL(tokenTTL time.Duration)
 }
 
 type Auth struct {
-	secretKey []byte
-	tokenTTL  time.Duration
+	keyring  *Keyring
+	tokenTTL atomic.Int64
 }
 
-func InitAuth(secretKey []byte, tokenTTL time.Duration) {
-	once.Do(func() {
-		instance = &Auth{
-			secretKey: secretKey,
-			tokenTTL:  tokenTTL,
-		}
-	})
-}
-
-func GetAuth() *Auth {
-	if instance == nil {
-		panic("auth package is not initialized. Call InitAuth first.")
-	}
-	return instance
+// NewAuth creates the access token signer. Tokens are issued with the
+// keyring's signing key and verified with whichever key the footer names.
+// tokenTTL is the lifetime of an access token; sessions are extended with
+// refresh tokens.
+func NewAuth(keyring *Keyring, tokenTTL time.Duration) *Auth {
+	a := &Auth{keyring: keyring}
+	a.tokenTTL.Store(int64(tokenTTL))
+	return a
 }
 
 type TokenPayload struct {
 	UserID   int64  `json:"userID"`
 	Username string `json:"username"`
+	Role     string `json:"role"`
+	TokenID  string `json:"jti"`
+	Iat      string `json:"iat"`
 	Exp      string `json:"exp"`
 }
 
+// UserRole defaults to player for tokens issued before roles were added.
+func (p TokenPayload) UserRole() entity.UserRole {
+	if p.Role == "" {
+		return entity.UserRolePlayer
+	}
+	return entity.UserRole(p.Role)
+}
+
+func (p TokenPayload) IssuedAt() time.Time {
+	iat, _ := time.Parse(time.RFC3339Nano, p.Iat)
+	return iat
+}
+
+func (p TokenPayload) ExpiresAt() time.Time {
+	exp, _ := time.Parse(time.RFC3339, p.Exp)
+	return exp
+}
+
+type tokenFooter struct {
+	KeyID string `json:"kid"`
+}
+
+func (a *Auth) TokenTTL() time.Duration {
+	return time.Duration(a.tokenTTL.Load())
+}
+
+// SetTokenTTL changes the lifetime of tokens issued from now on. Tokens
+// already issued keep their expiry.
+func (a *Auth) SetTokenTTL(tokenTTL time.Duration) {
+	a.tokenTTL.Store(int64(tokenTTL))
+}
+
+func (a *Auth) Keyring() *Keyring {
+	return a.keyring
+}
+
 func (a *Auth) GenerateToken(user *entity.User) (string, error) {
 	now := time.Now()
-	exp := now.Add(a.tokenTTL)
+	exp := now.Add(a.TokenTTL())
 
 	payload := TokenPayload{
 		UserID:   user.ID,
 		Username: user.Username,
+		Role:     string(user.Role),
+		TokenID:  uuid.NewString(),
+		Iat:      now.UTC().Format(time.RFC3339Nano),
 		Exp:      exp.Format(time.RFC3339),
 	}
 
-	token, err := paseto.NewV2().Encrypt(a.secretKey, payload, nil)
+	signingKey := a.keyring.signing()
+	footer, err := json.Marshal(tokenFooter{KeyID: signingKey.id})
+	if err != nil {
+		return "", err
+	}
+
+	var token string
+	if signingKey.keyType == KeyTypePublic {
+		token, err = signV4Public(signingKey.privateKey, payload, footer)
+	} else {
+		token, err = paseto.NewV2().Encrypt(signingKey.secret, payload, footer)
+	}
 	if err != nil {
 		return "", fmt.Errorf("token create error: %v", err)
 	}
@@ -64,8 +115,20 @@ func (a *Auth) GenerateToken(user *entity.User) (string, error) {
 }
 
 func (a *Auth) VerifyToken(token string) (TokenPayload, error) {
+	verificationKey, err := a.verificationKey(token)
+	if err != nil {
+		return TokenPayload{}, err
+	}
+
 	var payload TokenPayload
-	err := paseto.NewV2().Decrypt(token, a.secretKey, &payload, nil)
+	switch {
+	case strings.HasPrefix(token, v2LocalHeader) && verificationKey.keyType == KeyTypeLocal:
+		err = paseto.NewV2().Decrypt(token, verificationKey.secret, &payload, nil)
+	case strings.HasPrefix(token, v4PublicHeader) && verificationKey.keyType == KeyTypePublic:
+		err = verifyV4Public(token, verificationKey.publicKey, &payload)
+	default:
+		err = fmt.Errorf("key %q cannot verify this token", verificationKey.id)
+	}
 	if err != nil {
 		return TokenPayload{}, fmt.Errorf("token decrypt error: %v", err)
 	}
@@ -77,3 +140,29 @@ func (a *Auth) VerifyToken(token string) (TokenPayload, error) {
 
 	return payload, nil
 }
+
+// verificationKey picks the key named in the token footer. Tokens without a
+// key ID, whose footer is empty or "null", predate the keyring and are checked
+// against the default key.
+func (a *Auth) verificationKey(token string) (*key, error) {
+	keyID := DefaultKeyID
+	if parts := strings.Split(token, "."); len(parts) == 4 && parts[3] != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(parts[3])
+		if err != nil {
+			return nil, fmt.Errorf("invalid token footer")
+		}
+		var footer *tokenFooter
+		if err := json.Unmarshal(raw, &footer); err != nil {
+			return nil, fmt.Errorf("invalid token footer")
+		}
+		if footer != nil && footer.KeyID != "" {
+			keyID = footer.KeyID
+		}
+	}
+
+	verificationKey, ok := a.keyring.lookup(keyID)
+	if !ok {
+		return nil, fmt.Errorf("unknown token key %q", keyID)
+	}
+	return verificationKey, nil
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+const (
+	denylistTokenPrefix = "auth:denylist:"
+	denylistUserPrefix  = "auth:revoked-before:"
+)
+
+// ITokenDenylist kills access tokens before they expire, either one token by
+// its ID or every token a user was issued up to now.
+type ITokenDenylist interface {
+	Revoke(ctx context.Context, payload TokenPayload) error
+	RevokeUser(ctx context.Context, userID int64, maxTokenTTL time.Duration) error
+	IsRevoked(ctx context.Context, payload TokenPayload) (bool, error)
+}
+
+// RedisTokenDenylist keeps entries only as long as the tokens they refer to
+// could still be valid.
+type RedisTokenDenylist struct {
+	client *redis.Client
+}
+
+func NewRedisTokenDenylist(client *redis.Client) ITokenDenylist {
+	return &RedisTokenDenylist{client: client}
+}
+
+// ErrTokenWithoutID is returned when revoking a token that has no ID, which
+// can only be revoked together with the user's other tokens.
+var ErrTokenWithoutID = errors.New("token has no id")
+
+// Revoke revokes one token by its ID. A token that has already expired needs
+// no entry.
+func (d *RedisTokenDenylist) Revoke(ctx context.Context, payload TokenPayload) error {
+	if payload.TokenID == "" {
+		return ErrTokenWithoutID
+	}
+	ttl := time.Until(payload.ExpiresAt())
+	if ttl <= 0 {
+		return nil
+	}
+	return d.client.Set(ctx, denylistTokenPrefix+payload.TokenID, payload.UserID, ttl).Err()
+}
+
+// RevokeUser revokes every token issued to the user up to now. maxTokenTTL is
+// the longest lifetime any of them may have been issued with, so the marker
+// outlives tokens issued before the TTL was last shortened.
+func (d *RedisTokenDenylist) RevokeUser(ctx context.Context, userID int64, maxTokenTTL time.Duration) error {
+	revokedBefore := time.Now().UTC().UnixNano()
+	return d.client.Set(ctx, denylistUserPrefix+strconv.FormatInt(userID, 10), revokedBefore, maxTokenTTL).Err()
+}
+
+func (d *RedisTokenDenylist) IsRevoked(ctx context.Context, payload TokenPayload) (bool, error) {
+	var tokenRevoked *redis.IntCmd
+	var userRevokedBefore *redis.StringCmd
+	_, err := d.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		tokenRevoked = pipe.Exists(ctx, denylistTokenPrefix+payload.TokenID)
+		userRevokedBefore = pipe.Get(ctx, denylistUserPrefix+strconv.FormatInt(payload.UserID, 10))
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return false, err
+	}
+
+	if tokenRevoked.Val() > 0 {
+		return true, nil
+	}
+
+	revokedBefore, err := userRevokedBefore.Int64()
+	if err != nil {
+		return false, nil
+	}
+	return !payload.IssuedAt().After(time.Unix(0, revokedBefore)), nil
+}
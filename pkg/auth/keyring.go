@@ -0,0 +1,262 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"goodblast/pkg/log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	KeyTypeLocal  = "local"
+	KeyTypePublic = "public"
+
+	// DefaultKeyID is assumed for tokens issued before key IDs were added to
+	// the footer.
+	DefaultKeyID = "default"
+
+	localKeySize = 32
+)
+
+// KeyringConfig lists the keys tokens can be verified with and names the one
+// new tokens are issued with. Local keys are 32-byte secrets used for
+// v2.local tokens; public keys are Ed25519 keys used for v4.public tokens and
+// need only the public half for verification.
+type KeyringConfig struct {
+	SigningKeyID string      `json:"signingKeyId"`
+	Keys         []KeyConfig `json:"keys"`
+}
+
+type KeyConfig struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	// Secret is the raw 32-byte secret of a local key.
+	Secret string `json:"secret,omitempty"`
+	// PrivateKey is the base64 encoded Ed25519 seed or private key.
+	PrivateKey string `json:"privateKey,omitempty"`
+	// PublicKey is the base64 encoded Ed25519 public key. It is derived from
+	// PrivateKey when omitted.
+	PublicKey string `json:"publicKey,omitempty"`
+}
+
+// PublicKey is a verification key that can be shared with other services.
+type PublicKey struct {
+	KeyID     string `json:"kid"`
+	Version   string `json:"version"`
+	PublicKey string `json:"publicKey"`
+}
+
+type key struct {
+	id         string
+	keyType    string
+	secret     []byte
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+}
+
+type Keyring struct {
+	mu         sync.RWMutex
+	signingKey *key
+	keys       map[string]*key
+	legacyKey  *key
+}
+
+func NewKeyring(config KeyringConfig) (*Keyring, error) {
+	keyring := &Keyring{}
+	if err := keyring.Load(config); err != nil {
+		return nil, err
+	}
+	return keyring, nil
+}
+
+// NewSecretKeyring wraps a single symmetric secret, used when no keyring is
+// configured.
+func NewSecretKeyring(secret string) (*Keyring, error) {
+	return NewKeyring(KeyringConfig{
+		SigningKeyID: DefaultKeyID,
+		Keys:         []KeyConfig{{ID: DefaultKeyID, Type: KeyTypeLocal, Secret: secret}},
+	})
+}
+
+func ParseKeyringConfig(data []byte) (KeyringConfig, error) {
+	var config KeyringConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return KeyringConfig{}, fmt.Errorf("invalid keyring: %w", err)
+	}
+	return config, nil
+}
+
+// Load replaces the keys atomically. The current keys stay in use if config
+// is invalid.
+func (k *Keyring) Load(config KeyringConfig) error {
+	keys := make(map[string]*key, len(config.Keys))
+	for _, keyConfig := range config.Keys {
+		parsed, err := parseKey(keyConfig)
+		if err != nil {
+			return err
+		}
+		if _, exists := keys[parsed.id]; exists {
+			return fmt.Errorf("duplicate key id %q", parsed.id)
+		}
+		keys[parsed.id] = parsed
+	}
+
+	signingKey, ok := keys[config.SigningKeyID]
+	if !ok {
+		return fmt.Errorf("signing key %q is not in the keyring", config.SigningKeyID)
+	}
+	if signingKey.keyType == KeyTypePublic && signingKey.privateKey == nil {
+		return fmt.Errorf("signing key %q has no private key", config.SigningKeyID)
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.signingKey = signingKey
+	k.mu.Unlock()
+	return nil
+}
+
+// SetLegacySecret keeps tokens issued with the old single secret verifiable
+// after moving to a keyring that does not list a default key.
+func (k *Keyring) SetLegacySecret(secret string) error {
+	legacyKey, err := parseKey(KeyConfig{ID: DefaultKeyID, Type: KeyTypeLocal, Secret: secret})
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	k.legacyKey = legacyKey
+	k.mu.Unlock()
+	return nil
+}
+
+// PublicKeys returns the v4.public verification keys.
+func (k *Keyring) PublicKeys() []PublicKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	var publicKeys []PublicKey
+	for _, key := range k.keys {
+		if key.keyType != KeyTypePublic {
+			continue
+		}
+		publicKeys = append(publicKeys, PublicKey{
+			KeyID:     key.id,
+			Version:   "v4.public",
+			PublicKey: base64.StdEncoding.EncodeToString(key.publicKey),
+		})
+	}
+	sort.Slice(publicKeys, func(i, j int) bool { return publicKeys[i].KeyID < publicKeys[j].KeyID })
+	return publicKeys
+}
+
+// WatchFile reloads the keyring whenever the file content changes, checking
+// every interval until ctx is cancelled.
+func (k *Keyring) WatchFile(ctx context.Context, path string, interval time.Duration) {
+	last, _ := os.ReadFile(path)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.GetLogger().WithError(err).Errorf("Failed to read keyring file %s", path)
+			continue
+		}
+		if bytes.Equal(data, last) {
+			continue
+		}
+
+		config, err := ParseKeyringConfig(data)
+		if err == nil {
+			err = k.Load(config)
+		}
+		if err != nil {
+			log.GetLogger().WithError(err).Errorf("Keeping current keyring, failed to reload %s", path)
+			continue
+		}
+
+		last = data
+		log.GetLogger().Infof("Keyring reloaded from %s, signing key is %q", path, config.SigningKeyID)
+	}
+}
+
+func (k *Keyring) signing() *key {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.signingKey
+}
+
+func (k *Keyring) lookup(id string) (*key, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	found, ok := k.keys[id]
+	if !ok && id == DefaultKeyID && k.legacyKey != nil {
+		return k.legacyKey, true
+	}
+	return found, ok
+}
+
+func parseKey(config KeyConfig) (*key, error) {
+	if config.ID == "" {
+		return nil, errors.New("key id is required")
+	}
+
+	switch config.Type {
+	case KeyTypeLocal:
+		if len(config.Secret) != localKeySize {
+			return nil, fmt.Errorf("local key %q must be %d bytes", config.ID, localKeySize)
+		}
+		return &key{id: config.ID, keyType: KeyTypeLocal, secret: []byte(config.Secret)}, nil
+
+	case KeyTypePublic:
+		parsed := &key{id: config.ID, keyType: KeyTypePublic}
+		if config.PrivateKey != "" {
+			raw, err := base64.StdEncoding.DecodeString(config.PrivateKey)
+			if err != nil {
+				return nil, fmt.Errorf("public key %q has an invalid private key: %w", config.ID, err)
+			}
+			switch len(raw) {
+			case ed25519.SeedSize:
+				parsed.privateKey = ed25519.NewKeyFromSeed(raw)
+			case ed25519.PrivateKeySize:
+				parsed.privateKey = ed25519.PrivateKey(raw)
+			default:
+				return nil, fmt.Errorf("public key %q has an invalid private key size", config.ID)
+			}
+			parsed.publicKey = parsed.privateKey.Public().(ed25519.PublicKey)
+		}
+		if config.PublicKey != "" {
+			raw, err := base64.StdEncoding.DecodeString(config.PublicKey)
+			if err != nil || len(raw) != ed25519.PublicKeySize {
+				return nil, fmt.Errorf("public key %q has an invalid public key", config.ID)
+			}
+			if parsed.publicKey != nil && !bytes.Equal(parsed.publicKey, raw) {
+				return nil, fmt.Errorf("public key %q does not match its private key", config.ID)
+			}
+			parsed.publicKey = raw
+		}
+		if parsed.publicKey == nil {
+			return nil, fmt.Errorf("public key %q needs a private or public key", config.ID)
+		}
+		return parsed, nil
+
+	default:
+		return nil, fmt.Errorf("key %q has unknown type %q", config.ID, config.Type)
+	}
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// PASETO v4.public tokens: Ed25519 signatures over the pre-authentication
+// encoding of header, message, footer and an empty implicit assertion. They
+// can be verified with the public key alone.
+const v4PublicHeader = "v4.public."
+
+var errInvalidV4Token = errors.New("invalid v4.public token")
+
+func signV4Public(privateKey ed25519.PrivateKey, payload interface{}, footer []byte) (string, error) {
+	message, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	signature := ed25519.Sign(privateKey, preAuthEncode([]byte(v4PublicHeader), message, footer, nil))
+
+	token := v4PublicHeader + base64.RawURLEncoding.EncodeToString(append(message, signature...))
+	if len(footer) > 0 {
+		token += "." + base64.RawURLEncoding.EncodeToString(footer)
+	}
+	return token, nil
+}
+
+func verifyV4Public(token string, publicKey ed25519.PublicKey, payload interface{}) error {
+	if !strings.HasPrefix(token, v4PublicHeader) {
+		return errInvalidV4Token
+	}
+
+	parts := strings.Split(token[len(v4PublicHeader):], ".")
+	if len(parts) > 2 {
+		return errInvalidV4Token
+	}
+
+	body, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || len(body) < ed25519.SignatureSize {
+		return errInvalidV4Token
+	}
+
+	var footer []byte
+	if len(parts) == 2 {
+		if footer, err = base64.RawURLEncoding.DecodeString(parts[1]); err != nil {
+			return errInvalidV4Token
+		}
+	}
+
+	message := body[:len(body)-ed25519.SignatureSize]
+	signature := body[len(body)-ed25519.SignatureSize:]
+	if !ed25519.Verify(publicKey, preAuthEncode([]byte(v4PublicHeader), message, footer, nil), signature) {
+		return errInvalidV4Token
+	}
+
+	return json.Unmarshal(message, payload)
+}
+
+func preAuthEncode(pieces ...[]byte) []byte {
+	size := 8
+	for _, piece := range pieces {
+		size += 8 + len(piece)
+	}
+
+	encoded := make([]byte, 0, size)
+	encoded = appendLE64(encoded, len(pieces))
+	for _, piece := range pieces {
+		encoded = appendLE64(encoded, len(piece))
+		encoded = append(encoded, piece...)
+	}
+	return encoded
+}
+
+func appendLE64(dst []byte, n int) []byte {
+	return binary.LittleEndian.AppendUint64(dst, uint64(n)&(1<<63-1))
+}
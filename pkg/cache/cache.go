@@ -1,40 +1,269 @@
 package cache
 
 import (
+	"context"
 	"encoding/json"
-	"github.com/patrickmn/go-cache"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+	"goodblast/pkg/log"
 	"time"
 )
 
-var leaderboardCache *cache.Cache
+const (
+	defaultSize           = 10000
+	redisKeyPrefix        = "cache:"
+	invalidationSuffix    = ":invalidate"
+	resubscribeRetryDelay = time.Second
+	// loadTimeout bounds a shared load, which no longer ends with the request
+	// that started it.
+	loadTimeout = 10 * time.Second
+)
+
+// Cache is a read-through cache with a bounded in-process LRU tier in front of
+// an optional shared Redis tier. Values are stored as JSON so both tiers hold
+// the same bytes.
+type Cache interface {
+	Get(ctx context.Context, key string, target interface{}) (bool, error)
+	Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+	// GetOrLoad fills target from the cache or, on a miss, from load. Concurrent
+	// misses for the same key share a single load, which keeps running when
+	// the caller that started it goes away.
+	GetOrLoad(ctx context.Context, key string, target interface{}, ttl time.Duration, load func(ctx context.Context) (interface{}, error)) error
+	// Delete removes keys from both tiers and tells every other instance to
+	// drop its local copy.
+	Delete(ctx context.Context, keys ...string) error
+	// Listen applies invalidations published by other instances until ctx is
+	// cancelled. It returns immediately when no Redis client is configured.
+	Listen(ctx context.Context)
+}
+
+type Options struct {
+	// Name namespaces Redis keys and labels the metrics.
+	Name string
+	// Size bounds the number of entries held locally.
+	Size int
+	// LocalTTL caps how long an entry lives in the local tier; zero uses the
+	// entry TTL.
+	LocalTTL time.Duration
+	// Redis carries invalidations between instances.
+	Redis *redis.Client
+	// SharedTier also stores entries in Redis so instances can serve each
+	// other's loads. Requires Redis.
+	SharedTier bool
+}
 
-func InitCache() {
-	leaderboardCache = cache.New(30*time.Second, 60*time.Second)
+type tieredCache struct {
+	name     string
+	local    *lru
+	localTTL time.Duration
+	redis    *redis.Client
+	shared   bool
+	loads    singleflight.Group
+}
+
+func New(opts Options) Cache {
+	if opts.Size <= 0 {
+		opts.Size = defaultSize
+	}
+	return &tieredCache{
+		name:     opts.Name,
+		local:    newLRU(opts.Size),
+		localTTL: opts.LocalTTL,
+		redis:    opts.Redis,
+		shared:   opts.Redis != nil && opts.SharedTier,
+	}
+}
+
+func (c *tieredCache) Get(ctx context.Context, key string, target interface{}) (bool, error) {
+	data, found, err := c.lookup(ctx, key)
+	if err != nil || !found {
+		return false, err
+	}
+
+	if err := json.Unmarshal(data, target); err != nil {
+		c.local.remove(key)
+		return false, err
+	}
+	return true, nil
 }
 
-func SetCache(key string, data interface{}, expiration time.Duration) {
-	jsonData, err := json.Marshal(data)
+func (c *tieredCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
 	if err != nil {
-		return
+		return err
 	}
-	leaderboardCache.Set(key, jsonData, expiration)
+	return c.store(ctx, key, data, ttl)
 }
 
-func GetCache(key string, target interface{}) (bool, error) {
-	cachedData, found := leaderboardCache.Get(key)
+func (c *tieredCache) GetOrLoad(ctx context.Context, key string, target interface{}, ttl time.Duration, load func(ctx context.Context) (interface{}, error)) error {
+	data, found, err := c.lookup(ctx, key)
+	if err != nil {
+		log.GetLogger().WithError(err).Warnf("Cache %s lookup failed for %s", c.name, key)
+	}
+
 	if !found {
-		return false, nil
+		loaded := c.loads.DoChan(key, func() (interface{}, error) {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), loadTimeout)
+			defer cancel()
+
+			value, err := load(ctx)
+			if err != nil {
+				return nil, err
+			}
+			data, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			if err := c.store(ctx, key, data, ttl); err != nil {
+				log.GetLogger().WithError(err).Warnf("Cache %s store failed for %s", c.name, key)
+			}
+			return data, nil
+		})
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case result := <-loaded:
+			if result.Err != nil {
+				return result.Err
+			}
+			data = result.Val.([]byte)
+		}
+	}
+
+	return json.Unmarshal(data, target)
+}
+
+func (c *tieredCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
 	}
 
-	jsonData, ok := cachedData.([]byte)
-	if !ok {
-		return false, nil
+	for _, key := range keys {
+		c.local.remove(key)
+	}
+	if c.redis == nil {
+		return nil
 	}
 
-	err := json.Unmarshal(jsonData, target)
+	payload, err := json.Marshal(keys)
 	if err != nil {
-		return false, err
+		return err
 	}
 
-	return true, nil
+	_, err = c.redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		if c.shared {
+			redisKeys := make([]string, len(keys))
+			for i, key := range keys {
+				redisKeys[i] = c.redisKey(key)
+			}
+			pipe.Del(ctx, redisKeys...)
+		}
+		pipe.Publish(ctx, c.invalidationChannel(), payload)
+		return nil
+	})
+	return err
+}
+
+func (c *tieredCache) Listen(ctx context.Context) {
+	if c.redis == nil {
+		return
+	}
+
+	for ctx.Err() == nil {
+		c.listen(ctx)
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(resubscribeRetryDelay):
+		}
+	}
+}
+
+func (c *tieredCache) listen(ctx context.Context) {
+	pubsub := c.redis.Subscribe(ctx, c.invalidationChannel())
+	defer pubsub.Close()
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		log.GetLogger().WithError(err).Errorf("Failed to subscribe to cache %s invalidations", c.name)
+		return
+	}
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			var keys []string
+			if err := json.Unmarshal([]byte(msg.Payload), &keys); err != nil {
+				log.GetLogger().WithError(err).Errorf("Failed to unmarshal cache %s invalidation", c.name)
+				continue
+			}
+			for _, key := range keys {
+				c.local.remove(key)
+			}
+			invalidations.WithLabelValues(c.name).Add(float64(len(keys)))
+		}
+	}
+}
+
+func (c *tieredCache) lookup(ctx context.Context, key string) ([]byte, bool, error) {
+	if data, found := c.local.get(key); found {
+		recordLookup(c.name, tierLocal, true)
+		return data, true, nil
+	}
+	recordLookup(c.name, tierLocal, false)
+
+	if !c.shared {
+		return nil, false, nil
+	}
+
+	var get *redis.StringCmd
+	var ttl *redis.DurationCmd
+	_, err := c.redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, c.redisKey(key))
+		ttl = pipe.PTTL(ctx, c.redisKey(key))
+		return nil
+	})
+	if errors.Is(err, redis.Nil) {
+		recordLookup(c.name, tierRedis, false)
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	recordLookup(c.name, tierRedis, true)
+
+	data, _ := get.Bytes()
+	c.local.set(key, data, c.localExpiry(ttl.Val()))
+	return data, true, nil
+}
+
+func (c *tieredCache) store(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	c.local.set(key, data, c.localExpiry(ttl))
+	if !c.shared {
+		return nil
+	}
+	return c.redis.Set(ctx, c.redisKey(key), data, ttl).Err()
+}
+
+func (c *tieredCache) localExpiry(ttl time.Duration) time.Duration {
+	if c.localTTL > 0 && (ttl <= 0 || c.localTTL < ttl) {
+		return c.localTTL
+	}
+	return ttl
+}
+
+func (c *tieredCache) redisKey(key string) string {
+	return redisKeyPrefix + c.name + ":" + key
+}
+
+func (c *tieredCache) invalidationChannel() string {
+	return redisKeyPrefix + c.name + invalidationSuffix
 }
@@ -0,0 +1,31 @@
+package cache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	tierLocal = "local"
+	tierRedis = "redis"
+)
+
+var (
+	lookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "goodblast_cache_lookups_total",
+		Help: "Cache lookups by cache, tier and result.",
+	}, []string{"cache", "tier", "result"})
+
+	invalidations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "goodblast_cache_remote_invalidations_total",
+		Help: "Keys dropped from the local tier because another instance invalidated them.",
+	}, []string{"cache"})
+)
+
+func recordLookup(cache, tier string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	lookups.WithLabelValues(cache, tier, result).Inc()
+}
@@ -1,3 +1,9 @@
 package constants
 
 const CorrelationIdKey string = "X-CorrelationId"
+
+const TokenPayloadKey string = "tokenPayload"
+
+const RoleKey string = "role"
+
+const ServiceIDKey string = "serviceID"
@@ -0,0 +1,122 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	StatusHealthy     = "healthy"
+	StatusDegraded    = "degraded"
+	StatusUnavailable = "unavailable"
+)
+
+// defaultCheckTimeout bounds a check registered without a timeout.
+const defaultCheckTimeout = 2 * time.Second
+
+// Check probes one dependency. A failing critical check makes the instance
+// unavailable; a failing non-critical one only degrades it.
+type Check struct {
+	Name     string
+	Timeout  time.Duration
+	Critical bool
+	Check    func(ctx context.Context) error
+}
+
+type CheckResult struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Critical   bool   `json:"critical"`
+	DurationMs int64  `json:"durationMs"`
+	Error      string `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// Registry holds the readiness checks of the components.
+type Registry struct {
+	mutex  sync.RWMutex
+	checks []Check
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a readiness check. Checks are reported in registration order.
+func (r *Registry) Register(check Check) {
+	if check.Timeout <= 0 {
+		check.Timeout = defaultCheckTimeout
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.checks = append(r.checks, check)
+}
+
+// Run runs every check concurrently, each bounded by its own timeout.
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mutex.RLock()
+	checks := make([]Check, len(r.checks))
+	copy(checks, r.checks)
+	r.mutex.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = runCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusHealthy, Checks: results}
+	for _, result := range results {
+		if result.Status == StatusHealthy {
+			continue
+		}
+		if result.Critical {
+			report.Status = StatusUnavailable
+			break
+		}
+		report.Status = StatusDegraded
+	}
+	return report
+}
+
+func runCheck(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	startedAt := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- check.Check(ctx)
+	}()
+
+	// A check that ignores its context must not hold up the report.
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := CheckResult{
+		Name:       check.Name,
+		Status:     StatusHealthy,
+		Critical:   check.Critical,
+		DurationMs: time.Since(startedAt).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = StatusUnavailable
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,111 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Hook starts and stops one component. Either function may be nil.
+type Hook struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// Lifecycle starts components in the order they were appended and stops them
+// in reverse, so a component is stopped before the ones it depends on.
+type Lifecycle struct {
+	mutex   sync.Mutex
+	pending []Hook
+	running []Hook
+}
+
+func New() *Lifecycle {
+	return &Lifecycle{}
+}
+
+// Append registers a hook. A hook without a Start function owns a resource
+// opened while the application was built, like a connection, and counts as
+// running right away so Stop releases it even if Start is never called.
+func (l *Lifecycle) Append(hook Hook) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if hook.Start == nil {
+		l.running = append(l.running, hook)
+		return
+	}
+	l.pending = append(l.pending, hook)
+}
+
+// Start runs the pending Start functions in order. When one fails the hooks
+// already running are stopped and the error is returned.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	l.mutex.Lock()
+	pending := l.pending
+	l.pending = nil
+	l.mutex.Unlock()
+
+	for _, hook := range pending {
+		if err := hook.Start(ctx); err != nil {
+			startErr := fmt.Errorf("failed to start %s: %w", hook.Name, err)
+			return errors.Join(startErr, l.Stop(context.WithoutCancel(ctx)))
+		}
+		l.mutex.Lock()
+		l.running = append(l.running, hook)
+		l.mutex.Unlock()
+	}
+	return nil
+}
+
+// Stop runs the Stop functions of the running hooks in reverse order. Every
+// hook is stopped even when an earlier one fails; the errors are joined.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.mutex.Lock()
+	running := l.running
+	l.running = nil
+	l.mutex.Unlock()
+
+	var errs []error
+	for i := len(running) - 1; i >= 0; i-- {
+		hook := running[i]
+		if hook.Stop == nil {
+			continue
+		}
+		if err := hook.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", hook.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Background returns a hook that runs fn on its own goroutine until the hook
+// is stopped. Stop cancels the context passed to fn and waits for it to return.
+func Background(name string, fn func(ctx context.Context)) Hook {
+	var cancel context.CancelFunc
+	done := make(chan struct{})
+
+	return Hook{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			var runCtx context.Context
+			runCtx, cancel = context.WithCancel(context.WithoutCancel(ctx))
+			go func() {
+				defer close(done)
+				fn(runCtx)
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
@@ -0,0 +1,67 @@
+package log
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Field names attached to log entries from a request or message context.
+const (
+	FieldCorrelationID = "correlation_id"
+	FieldUserID        = "user_id"
+	FieldRoute         = "route"
+	FieldTournamentID  = "tournament_id"
+	FieldTraceID       = "trace_id"
+	FieldSpanID        = "span_id"
+)
+
+type fieldsKey struct{}
+
+// WithField returns a copy of ctx whose log entries carry key=value in
+// addition to the fields already attached to ctx.
+func WithField(ctx context.Context, key string, value interface{}) context.Context {
+	existing, _ := ctx.Value(fieldsKey{}).(logrus.Fields)
+	fields := make(logrus.Fields, len(existing)+1)
+	for k, v := range existing {
+		fields[k] = v
+	}
+	fields[key] = value
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return WithField(ctx, FieldCorrelationID, correlationID)
+}
+
+func WithUserID(ctx context.Context, userID int64) context.Context {
+	return WithField(ctx, FieldUserID, userID)
+}
+
+func WithRoute(ctx context.Context, route string) context.Context {
+	return WithField(ctx, FieldRoute, route)
+}
+
+func WithTournamentID(ctx context.Context, tournamentID int64) context.Context {
+	return WithField(ctx, FieldTournamentID, tournamentID)
+}
+
+// FromContext returns an entry of the application logger carrying the fields
+// attached to ctx, like the correlation ID and user ID of the request, and the
+// IDs of the span ctx carries.
+func FromContext(ctx context.Context) *logrus.Entry {
+	entry := logrus.NewEntry(GetLogger())
+	if ctx == nil {
+		return entry
+	}
+	if fields, ok := ctx.Value(fieldsKey{}).(logrus.Fields); ok {
+		entry = entry.WithFields(fields)
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		entry = entry.WithFields(logrus.Fields{
+			FieldTraceID: spanContext.TraceID().String(),
+			FieldSpanID:  spanContext.SpanID().String(),
+		})
+	}
+	return entry.WithContext(ctx)
+}
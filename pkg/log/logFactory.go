@@ -4,44 +4,85 @@ import (
 	"github.com/sirupsen/logrus"
 	"goodblast/config"
 	"os"
-	"sync"
+	"sync/atomic"
 )
 
-var (
-	loggerInstance *logrus.Logger
-	once           sync.Once
+const (
+	FormatJSON = "json"
+	FormatText = "text"
 )
 
-func InitLogger(config appconfig.Config) {
-	once.Do(func() {
-		logger := logrus.New()
+var loggerInstance atomic.Pointer[logrus.Logger]
 
-		loggerMetaData := logrus.Fields{
-			"app_name": config.AppName,
-			"profile":  config.Env,
-		}
+// NewLogger builds the application logger. It tags every entry with the
+// application name and profile and writes JSON unless text is configured, or
+// the profile is not prod and no format is configured.
+func NewLogger(config appconfig.Config) *logrus.Logger {
+	logger := logrus.New()
 
-		logger.SetLevel(logrus.InfoLevel)
-		logrus.SetOutput(os.Stdout)
+	loggerMetaData := logrus.Fields{
+		"app_name": config.AppName,
+		"profile":  config.Env,
+	}
 
-		logger.SetFormatter(&logrus.TextFormatter{
-			FullTimestamp: true,
-			ForceColors:   true,
-		})
+	logger.SetLevel(logrus.InfoLevel)
+	logger.SetOutput(os.Stdout)
+	logrus.SetOutput(os.Stdout)
 
-		logger.AddHook(&MetadataHook{Fields: loggerMetaData})
+	formatter := newFormatter(config)
+	logger.SetFormatter(formatter)
+	logrus.SetFormatter(formatter)
 
-		logger.WithFields(loggerMetaData).Info("Logger Initialized")
+	logger.AddHook(&MetadataHook{Fields: loggerMetaData})
 
-		loggerInstance = logger
-	})
+	return logger
 }
 
+func newFormatter(config appconfig.Config) logrus.Formatter {
+	format := config.LogFormat
+	if format == "" && config.Env == "prod" {
+		format = FormatJSON
+	}
+
+	if format == FormatJSON {
+		return &logrus.JSONFormatter{
+			FieldMap: logrus.FieldMap{
+				logrus.FieldKeyTime: "timestamp",
+				logrus.FieldKeyMsg:  "message",
+			},
+		}
+	}
+	return &logrus.TextFormatter{
+		FullTimestamp: true,
+		ForceColors:   true,
+	}
+}
+
+// SetLogger makes logger the one GetLogger returns.
+func SetLogger(logger *logrus.Logger) {
+	loggerInstance.Store(logger)
+}
+
+// GetLogger returns the logger installed with SetLogger, or logrus' standard
+// logger when none has been installed yet, so packages can log before the
+// application is wired and from tests.
 func GetLogger() *logrus.Logger {
-	if loggerInstance == nil {
-		panic("logger is not initialized. Call InitLogger first.")
+	if logger := loggerInstance.Load(); logger != nil {
+		return logger
 	}
-	return loggerInstance
+	return logrus.StandardLogger()
+}
+
+// SetLevel changes the level of the application logger at runtime. level is
+// one of trace, debug, info, warn, error, fatal or panic.
+func SetLevel(level string) error {
+	parsed, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	GetLogger().SetLevel(parsed)
+	logrus.SetLevel(parsed)
+	return nil
 }
 
 type MetadataHook struct {
@@ -0,0 +1,34 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const offsetCursorPrefix = "o:"
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+func EncodeOffsetCursor(offset int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(offsetCursorPrefix + strconv.FormatInt(offset, 10)))
+}
+
+func DecodeOffsetCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	value, found := strings.CutPrefix(string(raw), offsetCursorPrefix)
+	if !found {
+		return 0, ErrInvalidCursor
+	}
+
+	offset, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || offset < 0 {
+		return 0, ErrInvalidCursor
+	}
+	return offset, nil
+}
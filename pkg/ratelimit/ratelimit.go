@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+const keyPrefix = "ratelimit:"
+
+// Limit allows at most Limit requests for Key within any Window.
+type Limit struct {
+	Key    string
+	Limit  int
+	Window time.Duration
+}
+
+// Result is the outcome of one request against its limits. When the request
+// was rejected, Rejected is the index of the limit it exceeded and RetryAfter
+// tells when the oldest counted request leaves that limit's window.
+type Result struct {
+	Allowed    bool
+	Rejected   int
+	RetryAfter time.Duration
+}
+
+type ILimiter interface {
+	Allow(ctx context.Context, limits ...Limit) (Result, error)
+}
+
+// slidingWindowScript keeps one sorted set member per allowed request, scored
+// by its time in milliseconds. Members older than the window are dropped
+// before counting, so the limit holds for any window, not just aligned ones.
+// Every limit is checked before the request is recorded against any of them,
+// so a request rejected by one limit does not use up the others, and rejected
+// requests do not extend the wait. The time comes from Redis, so instances
+// with skewed clocks still share one window.
+var slidingWindowScript = redis.NewScript(`
+redis.replicate_commands()
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+for i, key in ipairs(KEYS) do
+	local window = tonumber(ARGV[2 * i])
+	local limit = tonumber(ARGV[2 * i + 1])
+	redis.call("ZREMRANGEBYSCORE", key, "-inf", now - window)
+	if redis.call("ZCARD", key) >= limit then
+		local oldest = redis.call("ZRANGE", key, 0, 0, "WITHSCORES")
+		return {i, tonumber(oldest[2]) + window - now}
+	end
+end
+
+for i, key in ipairs(KEYS) do
+	redis.call("ZADD", key, now, ARGV[1])
+	redis.call("PEXPIRE", key, ARGV[2 * i])
+end
+return {0, 0}
+`)
+
+// SlidingWindowLimiter counts requests in Redis, so the limits hold across
+// every instance.
+type SlidingWindowLimiter struct {
+	client *redis.Client
+}
+
+func NewSlidingWindowLimiter(client *redis.Client) ILimiter {
+	return &SlidingWindowLimiter{client: client}
+}
+
+func (l *SlidingWindowLimiter) Allow(ctx context.Context, limits ...Limit) (Result, error) {
+	if len(limits) == 0 {
+		return Result{Allowed: true}, nil
+	}
+
+	keys := make([]string, 0, len(limits))
+	args := []interface{}{uuid.NewString()}
+	for _, limit := range limits {
+		keys = append(keys, keyPrefix+limit.Key)
+		args = append(args, limit.Window.Milliseconds(), limit.Limit)
+	}
+
+	values, err := slidingWindowScript.Run(ctx, l.client, keys, args...).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	if values[0] == 0 {
+		return Result{Allowed: true}, nil
+	}
+	return Result{
+		Rejected:   int(values[0]) - 1,
+		RetryAfter: time.Duration(values[1]) * time.Millisecond,
+	}, nil
+}
@@ -3,57 +3,72 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
-	"github.com/uptrace/bun"
 	appconfig "goodblast/config"
 	"goodblast/pkg/log"
+	"net"
 	"net/http"
-	"os/signal"
-	"syscall"
 	"time"
 )
 
+const shutdownTimeout = 30 * time.Second
+
+type shuttingDownKey struct{}
+
 type Server struct {
-	engine       *gin.Engine
-	dbConnection *bun.DB
+	engine *gin.Engine
 }
 
-func NewServer(engine *gin.Engine, dbConnection *bun.DB) *Server {
+func NewServer(engine *gin.Engine) *Server {
 	return &Server{
-		engine:       engine,
-		dbConnection: dbConnection,
+		engine: engine,
 	}
 }
 
-func (s *Server) StartHTTPServer(config *appconfig.Config) {
+// ShuttingDown returns a channel that is closed once the server handling the
+// request starts shutting down. Shutdown waits for every request to finish, so
+// long-lived responses like event streams must end when it is closed. Requests
+// that were not served by StartHTTPServer get a nil channel.
+func ShuttingDown(ctx context.Context) <-chan struct{} {
+	shuttingDown, _ := ctx.Value(shuttingDownKey{}).(<-chan struct{})
+	return shuttingDown
+}
+
+// StartHTTPServer serves until ctx is cancelled, then waits up to 30 seconds
+// for in-flight requests before returning.
+func (s *Server) StartHTTPServer(ctx context.Context, config *appconfig.Config) error {
 	addr := ":" + config.Port
 	logger := log.GetLogger()
 	logger.Infof("Starting server on http://localhost%s", addr)
+
+	// Request contexts are not cancelled on shutdown, so that in-flight
+	// requests can finish; streams watch ShuttingDown instead.
+	shuttingDown := make(chan struct{})
 	httpServer := &http.Server{
 		Addr:    addr,
 		Handler: s.engine,
+		BaseContext: func(net.Listener) context.Context {
+			return context.WithValue(context.Background(), shuttingDownKey{}, (<-chan struct{})(shuttingDown))
+		},
 	}
-	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	defer stop()
+	httpServer.RegisterOnShutdown(func() { close(shuttingDown) })
 
+	shutdownDone := make(chan error, 1)
 	go func() {
 		<-ctx.Done()
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 		defer cancel()
 		logger.Info("Shutting down server")
-		if err := s.dbConnection.Close(); err != nil {
-			logger.Warnf("Error closing Postgres connection: %#v", err)
-		} else {
-			logger.Info("Postgres connection closed successfully")
-		}
-		if err := httpServer.Shutdown(shutdownCtx); err != nil {
-			logger.Errorf("Error during server shutdown: %v", err)
-		}
-		logger.Info("Server exited")
+		shutdownDone <- httpServer.Shutdown(shutdownCtx)
 	}()
 
 	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		logger.Errorf("Failed to start server: %v", err)
-		panic("cannot start server")
+		return fmt.Errorf("cannot start server: %w", err)
+	}
+	if err := <-shutdownDone; err != nil {
+		return fmt.Errorf("error during server shutdown: %w", err)
 	}
+	logger.Info("Server exited")
+	return nil
 }
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"github.com/gin-gonic/gin"
+	appconfig "goodblast/config"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// An open event stream must not hold up shutdown: it ends once the server
+// starts shutting down, so StartHTTPServer returns without an error well
+// before the shutdown timeout.
+func TestStartHTTPServerEndsStreamsOnShutdown(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/stream", func(ctx *gin.Context) {
+		heartbeat := time.NewTicker(10 * time.Millisecond)
+		defer heartbeat.Stop()
+
+		shuttingDown := ShuttingDown(ctx.Request.Context())
+		ctx.Stream(func(w io.Writer) bool {
+			select {
+			case <-ctx.Request.Context().Done():
+				return false
+			case <-shuttingDown:
+				return false
+			case <-heartbeat.C:
+				ctx.SSEvent("heartbeat", time.Now().Unix())
+				return true
+			}
+		})
+	})
+
+	port := freePort(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	served := make(chan error, 1)
+	go func() {
+		served <- NewServer(engine).StartHTTPServer(ctx, &appconfig.Config{Port: port})
+	}()
+
+	stream := openStream(t, "http://localhost:"+port+"/stream")
+	defer stream.Body.Close()
+
+	line, err := bufio.NewReader(stream.Body).ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "event:heartbeat") {
+		t.Fatalf("first stream line = %q, %v; want a heartbeat event", line, err)
+	}
+
+	cancel()
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Fatalf("StartHTTPServer() = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("StartHTTPServer did not return with a stream open")
+	}
+}
+
+func TestShuttingDownOutsideServer(t *testing.T) {
+	if ShuttingDown(context.Background()) != nil {
+		t.Fatal("ShuttingDown() of a context not served by StartHTTPServer should be nil")
+	}
+}
+
+func freePort(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("cannot find a free port: %v", err)
+	}
+	defer listener.Close()
+	return strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+}
+
+// openStream retries until the server is listening.
+func openStream(t *testing.T, url string) *http.Response {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		response, err := http.Get(url)
+		if err == nil {
+			return response
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("cannot open the stream: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,111 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	appconfig "goodblast/config"
+	"strings"
+)
+
+// Exporters selectable with TracingExporter.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// OTLP protocols selectable with TracingOTLPProtocol.
+const (
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http/protobuf"
+)
+
+const tracerName = "goodblast"
+
+// Tracer returns the application tracer. Spans are dropped until
+// NewTracerProvider has installed a provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// NewTracerProvider builds the tracer provider for the configured exporter
+// and installs it, with W3C trace context and baggage propagation, as the
+// global one. It returns nil when tracing is disabled. The provider must be
+// shut down to flush buffered spans.
+func NewTracerProvider(ctx context.Context, config *appconfig.Config) (*sdktrace.TracerProvider, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporter, err := newExporter(ctx, config)
+	if err != nil || exporter == nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(config.AppName),
+		semconv.DeploymentEnvironmentName(config.Env),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build tracing resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.TracingSampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider, nil
+}
+
+func newExporter(ctx context.Context, config *appconfig.Config) (sdktrace.SpanExporter, error) {
+	switch strings.ToLower(config.TracingExporter) {
+	case "", ExporterNone:
+		return nil, nil
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterOTLP:
+		return newOTLPExporter(ctx, config)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q, expected %s, %s or %s",
+			config.TracingExporter, ExporterNone, ExporterStdout, ExporterOTLP)
+	}
+}
+
+// newOTLPExporter falls back to the standard OTEL_EXPORTER_OTLP_* variables
+// for whatever TracingOTLPEndpoint does not set.
+func newOTLPExporter(ctx context.Context, config *appconfig.Config) (*otlptrace.Exporter, error) {
+	switch config.TracingOTLPProtocol {
+	case "", ProtocolGRPC:
+		var options []otlptracegrpc.Option
+		if config.TracingOTLPEndpoint != "" {
+			options = append(options, otlptracegrpc.WithEndpoint(config.TracingOTLPEndpoint))
+		}
+		if config.TracingOTLPInsecure {
+			options = append(options, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, options...)
+	case ProtocolHTTP:
+		var options []otlptracehttp.Option
+		if config.TracingOTLPEndpoint != "" {
+			options = append(options, otlptracehttp.WithEndpoint(config.TracingOTLPEndpoint))
+		}
+		if config.TracingOTLPInsecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, options...)
+	default:
+		return nil, fmt.Errorf("unknown OTLP protocol %q, expected %s or %s",
+			config.TracingOTLPProtocol, ProtocolGRPC, ProtocolHTTP)
+	}
+}